@@ -0,0 +1,65 @@
+// app/adapters/notifieradapter/webhook.go
+package notifieradapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/config"
+	"github.com/BhanuChandraAraveti/cadence-example/app/worker/workflows"
+)
+
+// Webhook delivers reminders by posting them as JSON to a URL, e.g. a mailer
+// that emails the applicant or reviewer.
+type Webhook struct {
+	url        string
+	authHeader string
+	httpClient *http.Client
+}
+
+// NewWebhook creates a Webhook for the notifier in config. A zero timeout
+// defaults to ten seconds.
+func NewWebhook(config config.NotifierConfig) *Webhook {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &Webhook{
+		url:        config.WebhookURL,
+		authHeader: config.AuthHeader,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Notify posts the reminder to the webhook.
+func (w *Webhook) Notify(ctx context.Context, reminder workflows.Reminder) error {
+	requestBody, err := json.Marshal(reminder)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest("POST", w.url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return err
+	}
+	request = request.WithContext(ctx)
+	request.Header.Set("Content-Type", "application/json")
+	if w.authHeader != "" {
+		request.Header.Set("Authorization", w.authHeader)
+	}
+
+	response, err := w.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("notifier webhook returned %s", response.Status)
+	}
+	return nil
+}
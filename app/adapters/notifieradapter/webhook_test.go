@@ -0,0 +1,46 @@
+package notifieradapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/config"
+	"github.com/BhanuChandraAraveti/cadence-example/app/worker/workflows"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookPostsReminder(t *testing.T) {
+	var request *http.Request
+	var received workflows.Reminder
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	reminder := workflows.Reminder{ApplicantID: "applicant-1", Action: "basic-details", Time: time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)}
+	webhook := NewWebhook(config.NotifierConfig{WebhookURL: server.URL, AuthHeader: "Bearer secret"})
+
+	require.NoError(t, webhook.Notify(context.Background(), reminder))
+	require.Equal(t, "POST", request.Method)
+	require.Equal(t, "application/json", request.Header.Get("Content-Type"))
+	require.Equal(t, "Bearer secret", request.Header.Get("Authorization"))
+	require.Equal(t, reminder, received)
+}
+
+func TestWebhookFailsOnErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := NewWebhook(config.NotifierConfig{WebhookURL: server.URL}).Notify(context.Background(), workflows.Reminder{Action: "basic-details"})
+
+	require.EqualError(t, err, "notifier webhook returned 503 Service Unavailable")
+}
@@ -0,0 +1,202 @@
+// app/adapters/objectstore/local.go
+package objectstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/config"
+)
+
+// UploadPath is where Handler accepts uploads.
+const UploadPath = "/uploads/"
+
+// ErrNotFound is returned for keys without an object.
+var ErrNotFound = errors.New("object not found")
+
+// UploadTarget is a pre-signed upload: the client sends the file with Method
+// to URL before Expires, and it is stored under Key.
+type UploadTarget struct {
+	URL     string    `json:"url"`
+	Method  string    `json:"method"`
+	Key     string    `json:"key"`
+	Expires time.Time `json:"expires"`
+}
+
+// Local is an object store on the local filesystem for development and
+// tests. Objects are files under a root directory, named by their key.
+// Upload targets point at Handler, which checks their signature.
+type Local struct {
+	dir     string
+	baseURL string
+	secret  []byte
+	expiry  time.Duration
+	maxSize int64
+}
+
+// NewLocal creates a Local store for config. Without a directory objects are
+// kept under the system temp directory; without a secret upload targets are
+// signed with a random one, so only this process accepts them. A zero upload
+// expiry defaults to one hour.
+func NewLocal(config config.ObjectStoreConfig) *Local {
+	l := &Local{
+		dir:     config.Dir,
+		baseURL: strings.TrimSuffix(config.BaseURL, "/"),
+		secret:  []byte(config.Secret),
+		expiry:  config.UploadExpiry,
+		maxSize: config.MaxSize,
+	}
+	if l.dir == "" {
+		l.dir = filepath.Join(os.TempDir(), "cadence-example-objects")
+	}
+	if len(l.secret) == 0 {
+		l.secret = make([]byte, 32)
+		if _, err := rand.Read(l.secret); err != nil {
+			panic(err)
+		}
+	}
+	if l.expiry <= 0 {
+		l.expiry = time.Hour
+	}
+	return l
+}
+
+// PresignUpload returns an upload target for key.
+func (l *Local) PresignUpload(key string) (UploadTarget, error) {
+	if err := checkKey(key); err != nil {
+		return UploadTarget{}, err
+	}
+	expires := time.Now().Add(l.expiry).Truncate(time.Second)
+	query := "expires=" + strconv.FormatInt(expires.Unix(), 10) + "&signature=" + l.sign(key, expires.Unix())
+	return UploadTarget{
+		URL:     l.baseURL + UploadPath + key + "?" + query,
+		Method:  "PUT",
+		Key:     key,
+		Expires: expires,
+	}, nil
+}
+
+// Stat returns the size of the object stored under key.
+func (l *Local) Stat(ctx context.Context, key string) (int64, error) {
+	file, err := l.path(key)
+	if err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(file)
+	if os.IsNotExist(err) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// Open opens the object stored under key.
+func (l *Local) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	file, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Put stores r under key, replacing any object already there. The object
+// only appears once it is written completely.
+func (l *Local) Put(ctx context.Context, key string, r io.Reader) error {
+	file, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(file), ".upload-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// Handler accepts uploads to the targets returned by PresignUpload.
+func (l *Local) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" {
+			http.Error(w, "Invalid Method!"+r.Method, http.StatusMethodNotAllowed)
+			return
+		}
+		key := strings.TrimPrefix(r.URL.Path, UploadPath)
+		expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+		if err != nil || checkKey(key) != nil {
+			http.Error(w, "Invalid upload target!", http.StatusBadRequest)
+			return
+		}
+		if !hmac.Equal([]byte(r.URL.Query().Get("signature")), []byte(l.sign(key, expires))) {
+			http.Error(w, "Invalid signature!", http.StatusForbidden)
+			return
+		}
+		if time.Now().Unix() > expires {
+			http.Error(w, "Upload target expired!", http.StatusForbidden)
+			return
+		}
+
+		body := io.Reader(r.Body)
+		if l.maxSize > 0 {
+			body = http.MaxBytesReader(w, r.Body, l.maxSize)
+		}
+		if err := l.Put(r.Context(), key, body); err != nil {
+			http.Error(w, "Error storing upload!", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+}
+
+func (l *Local) sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, l.secret)
+	fmt.Fprintf(mac, "PUT\n%s\n%d", key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (l *Local) path(key string) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(key)), nil
+}
+
+// checkKey makes sure a key is a clean relative path, so objects stay under
+// the store directory.
+func checkKey(key string) error {
+	if key == "" || path.Clean(key) != key || path.IsAbs(key) || key == ".." || strings.HasPrefix(key, "../") {
+		return fmt.Errorf("invalid object key %q", key)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package objectstore
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T, maxSize int64) (*Local, *httptest.Server) {
+	server := httptest.NewUnstartedServer(nil)
+	store := NewLocal(config.ObjectStoreConfig{
+		Dir:     t.TempDir(),
+		BaseURL: "http://" + server.Listener.Addr().String() + "/",
+		Secret:  "test-secret",
+		MaxSize: maxSize,
+	})
+	server.Config.Handler = store.Handler()
+	server.Start()
+	t.Cleanup(server.Close)
+	return store, server
+}
+
+func upload(t *testing.T, target string, body string) int {
+	request, err := http.NewRequest("PUT", target, strings.NewReader(body))
+	require.NoError(t, err)
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	response.Body.Close()
+	return response.StatusCode
+}
+
+func TestLocalAcceptsSignedUploads(t *testing.T) {
+	store, _ := newTestStore(t, 0)
+	target, err := store.PresignUpload("uploads/applicant-1/video.mp4")
+	require.NoError(t, err)
+	require.Equal(t, "PUT", target.Method)
+
+	require.Equal(t, http.StatusCreated, upload(t, target.URL, "video"))
+
+	size, err := store.Stat(context.Background(), target.Key)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), size)
+	file, err := store.Open(context.Background(), target.Key)
+	require.NoError(t, err)
+	defer file.Close()
+	content, err := ioutil.ReadAll(file)
+	require.NoError(t, err)
+	require.Equal(t, "video", string(content))
+}
+
+func TestLocalRejectsInvalidUploads(t *testing.T) {
+	store, server := newTestStore(t, 8)
+	target, err := store.PresignUpload("uploads/video.mp4")
+	require.NoError(t, err)
+	signed, err := url.Parse(target.URL)
+	require.NoError(t, err)
+	query := signed.Query()
+
+	// A target signed by a store with another secret.
+	other := NewLocal(config.ObjectStoreConfig{Dir: t.TempDir(), BaseURL: server.URL, Secret: "other-secret"})
+	forged, err := other.PresignUpload("uploads/video.mp4")
+	require.NoError(t, err)
+
+	// A correctly signed target that expired.
+	past := time.Now().Add(-time.Minute).Unix()
+	expired := server.URL + UploadPath + "uploads/video.mp4?expires=" + strconv.FormatInt(past, 10) + "&signature=" + store.sign("uploads/video.mp4", past)
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		status int
+	}{
+		{"other key", server.URL + UploadPath + "uploads/other.mp4?" + query.Encode(), "video", http.StatusForbidden},
+		{"other secret", forged.URL, "video", http.StatusForbidden},
+		{"no signature", server.URL + UploadPath + "uploads/video.mp4?expires=" + query.Get("expires"), "video", http.StatusForbidden},
+		{"expired", expired, "video", http.StatusForbidden},
+		{"no expiry", server.URL + UploadPath + "uploads/video.mp4?signature=" + query.Get("signature"), "video", http.StatusBadRequest},
+		{"too large", target.URL, "larger than eight bytes", http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.status, upload(t, test.target, test.body))
+		})
+	}
+
+	_, err = store.Stat(context.Background(), "uploads/video.mp4")
+	require.Equal(t, ErrNotFound, err)
+}
+
+func TestLocalRejectsKeysOutsideItsDirectory(t *testing.T) {
+	store, _ := newTestStore(t, 0)
+	for _, key := range []string{"", "..", "../escape", "/absolute", "uploads/../../escape", "uploads//video.mp4", "uploads/./video.mp4", "uploads/"} {
+		_, err := store.PresignUpload(key)
+		require.Error(t, err, key)
+		_, err = store.Stat(context.Background(), key)
+		require.Error(t, err, key)
+		require.NotEqual(t, ErrNotFound, err, key)
+		require.Error(t, store.Put(context.Background(), key, strings.NewReader("x")), key)
+	}
+}
+
+func TestLocalMissingObjects(t *testing.T) {
+	store, _ := newTestStore(t, 0)
+
+	_, err := store.Stat(context.Background(), "missing")
+	require.Equal(t, ErrNotFound, err)
+	_, err = store.Open(context.Background(), "missing")
+	require.Equal(t, ErrNotFound, err)
+}
@@ -0,0 +1,106 @@
+// app/adapters/profileadapter/client.go
+package profileadapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/config"
+)
+
+// profilePath is the applicant-profile endpoint of the admin backend.
+const profilePath = "/teacher/applicant-profile"
+
+// profileRequest is the body of a PATCH to the applicant-profile endpoint.
+type profileRequest struct {
+	ApplicantID       string      `json:"applicant_id"`
+	ProfileAttributes interface{} `json:"profile_attributes"`
+}
+
+// Client talks to the applicant-profile endpoint of the admin backend.
+type Client struct {
+	url        string
+	authHeader string
+	httpClient *http.Client
+}
+
+// NewClient creates a Client for the backend in config. A zero timeout
+// defaults to ten seconds.
+func NewClient(config config.ProfileConfig) *Client {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &Client{
+		url:        strings.TrimSuffix(config.BaseURL, "/") + profilePath,
+		authHeader: config.AuthHeader,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// PatchProfile sets profile attributes of an applicant.
+func (c *Client) PatchProfile(ctx context.Context, applicantID string, attributes interface{}) (string, error) {
+	return c.patch(ctx, profileRequest{ApplicantID: applicantID, ProfileAttributes: attributes})
+}
+
+// AttachWorkflow records the workflow execution driving an applicant's journey.
+func (c *Client) AttachWorkflow(ctx context.Context, applicantID string, workflowID string, runID string) (string, error) {
+	return c.PatchProfile(ctx, applicantID, workflowAttributes{WorkflowID: workflowID, RunID: runID})
+}
+
+// CreateTeacher asks the backend to turn the applicant into a teacher.
+func (c *Client) CreateTeacher(ctx context.Context, applicantID string) (string, error) {
+	return c.PatchProfile(ctx, applicantID, teacherAttributes{CreateTeacher: true})
+}
+
+func (c *Client) patch(ctx context.Context, body profileRequest) (string, error) {
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return "ERROR BE call", err
+	}
+
+	request, err := http.NewRequest("PATCH", c.url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return "ERROR BE call", err
+	}
+	request = request.WithContext(ctx)
+	request.Header.Set("Content-Type", "application/json")
+	if c.authHeader != "" {
+		request.Header.Set("Authorization", c.authHeader)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return "ERROR BE call", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return response.Status, &StatusError{StatusCode: response.StatusCode, Status: response.Status}
+	}
+	return response.Status, nil
+}
+
+// StatusError is returned when the backend answers with a non-2xx status.
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("profile backend returned %s", e.Status)
+}
+
+type workflowAttributes struct {
+	WorkflowID string `json:"workflow_id"`
+	RunID      string `json:"run_id"`
+}
+
+type teacherAttributes struct {
+	CreateTeacher bool `json:"CREATE_TEACHER"`
+}
@@ -0,0 +1,106 @@
+package profileadapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordedRequest is what the test server received.
+type recordedRequest struct {
+	method        string
+	path          string
+	authorization string
+	contentType   string
+	body          map[string]interface{}
+}
+
+func newTestServer(t *testing.T, status int) (*httptest.Server, *[]recordedRequest) {
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := recordedRequest{
+			method:        r.Method,
+			path:          r.URL.Path,
+			authorization: r.Header.Get("Authorization"),
+			contentType:   r.Header.Get("Content-Type"),
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request.body)
+		requests = append(requests, request)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestClientPatchesProfile(t *testing.T) {
+	server, requests := newTestServer(t, http.StatusOK)
+	// A trailing slash on the base URL is not doubled.
+	client := NewClient(config.ProfileConfig{BaseURL: server.URL + "/", AuthHeader: "Bearer secret"})
+
+	status, err := client.PatchProfile(context.Background(), "applicant-1", map[string]interface{}{"name": "Asha"})
+
+	require.NoError(t, err)
+	require.Equal(t, "200 OK", status)
+	require.Equal(t, []recordedRequest{{
+		method:        "PATCH",
+		path:          "/teacher/applicant-profile",
+		authorization: "Bearer secret",
+		contentType:   "application/json",
+		body: map[string]interface{}{
+			"applicant_id":       "applicant-1",
+			"profile_attributes": map[string]interface{}{"name": "Asha"},
+		},
+	}}, *requests)
+}
+
+func TestClientSendsWorkflowAndTeacherAttributes(t *testing.T) {
+	server, requests := newTestServer(t, http.StatusNoContent)
+	client := NewClient(config.ProfileConfig{BaseURL: server.URL})
+
+	_, err := client.AttachWorkflow(context.Background(), "applicant-1", "workflow-1", "run-1")
+	require.NoError(t, err)
+	_, err = client.CreateTeacher(context.Background(), "applicant-1")
+	require.NoError(t, err)
+
+	require.Len(t, *requests, 2)
+	require.Empty(t, (*requests)[0].authorization, "no header without a configured one")
+	require.Equal(t, map[string]interface{}{"workflow_id": "workflow-1", "run_id": "run-1"}, (*requests)[0].body["profile_attributes"])
+	require.Equal(t, map[string]interface{}{"CREATE_TEACHER": true}, (*requests)[1].body["profile_attributes"])
+}
+
+func TestClientReturnsStatusErrors(t *testing.T) {
+	for _, code := range []int{http.StatusMultipleChoices, http.StatusBadRequest, http.StatusBadGateway} {
+		server, _ := newTestServer(t, code)
+
+		status, err := NewClient(config.ProfileConfig{BaseURL: server.URL}).PatchProfile(context.Background(), "applicant-1", nil)
+
+		var statusErr *StatusError
+		require.True(t, errors.As(err, &statusErr), "%d: %v", code, err)
+		require.Equal(t, code, statusErr.StatusCode)
+		require.Equal(t, status, statusErr.Status)
+		require.Equal(t, "profile backend returned "+status, err.Error())
+	}
+}
+
+func TestClientReportsUnreachableBackend(t *testing.T) {
+	server, _ := newTestServer(t, http.StatusOK)
+	server.Close()
+
+	status, err := NewClient(config.ProfileConfig{BaseURL: server.URL}).PatchProfile(context.Background(), "applicant-1", nil)
+
+	require.Error(t, err)
+	var statusErr *StatusError
+	require.False(t, errors.As(err, &statusErr))
+	require.Equal(t, "ERROR BE call", status)
+}
+
+func TestNewClientDefaultsTimeout(t *testing.T) {
+	require.Equal(t, "10s", NewClient(config.ProfileConfig{}).httpClient.Timeout.String())
+}
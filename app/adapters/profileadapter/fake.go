@@ -0,0 +1,77 @@
+// app/adapters/profileadapter/fake.go
+package profileadapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// Fake is an in-memory profile backend for tests and local runs. It records
+// the attributes patched for each applicant, in the shape the Client sends
+// them. Handler serves the same recording over HTTP, so a Client pointed at an
+// httptest.Server running it never leaves the process.
+type Fake struct {
+	// Err is returned by every call when set.
+	Err error
+
+	mu      sync.Mutex
+	patches map[string][]interface{}
+}
+
+// NewFake creates an empty Fake.
+func NewFake() *Fake {
+	return &Fake{patches: map[string][]interface{}{}}
+}
+
+// PatchProfile records attributes for the applicant.
+func (f *Fake) PatchProfile(ctx context.Context, applicantID string, attributes interface{}) (string, error) {
+	if f.Err != nil {
+		return "ERROR BE call", f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.patches[applicantID] = append(f.patches[applicantID], attributes)
+	return "200 OK", nil
+}
+
+// AttachWorkflow records the workflow execution for the applicant.
+func (f *Fake) AttachWorkflow(ctx context.Context, applicantID string, workflowID string, runID string) (string, error) {
+	return f.PatchProfile(ctx, applicantID, workflowAttributes{WorkflowID: workflowID, RunID: runID})
+}
+
+// CreateTeacher records that the applicant was turned into a teacher.
+func (f *Fake) CreateTeacher(ctx context.Context, applicantID string) (string, error) {
+	return f.PatchProfile(ctx, applicantID, teacherAttributes{CreateTeacher: true})
+}
+
+// Patches returns the attributes recorded for an applicant, oldest first.
+func (f *Fake) Patches(applicantID string) []interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]interface{}(nil), f.patches[applicantID]...)
+}
+
+// Handler serves the applicant-profile endpoint, recording patches as decoded
+// JSON.
+func (f *Fake) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(profilePath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PATCH" {
+			http.Error(w, "Invalid Method!"+r.Method, http.StatusMethodNotAllowed)
+			return
+		}
+		body := profileRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, err := f.PatchProfile(r.Context(), body.ApplicantID, body.ProfileAttributes); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
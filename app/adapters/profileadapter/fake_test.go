@@ -0,0 +1,66 @@
+package profileadapter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeRecordsPatches(t *testing.T) {
+	fake := NewFake()
+
+	status, err := fake.PatchProfile(context.Background(), "applicant-1", map[string]interface{}{"name": "Asha"})
+	require.NoError(t, err)
+	require.Equal(t, "200 OK", status)
+	_, err = fake.AttachWorkflow(context.Background(), "applicant-1", "workflow-1", "run-1")
+	require.NoError(t, err)
+	_, err = fake.CreateTeacher(context.Background(), "applicant-2")
+	require.NoError(t, err)
+
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"name": "Asha"},
+		workflowAttributes{WorkflowID: "workflow-1", RunID: "run-1"},
+	}, fake.Patches("applicant-1"))
+	require.Equal(t, []interface{}{teacherAttributes{CreateTeacher: true}}, fake.Patches("applicant-2"))
+	require.Empty(t, fake.Patches("applicant-3"))
+}
+
+func TestFakeReturnsErr(t *testing.T) {
+	fake := NewFake()
+	fake.Err = errors.New("backend down")
+
+	_, err := fake.CreateTeacher(context.Background(), "applicant-1")
+
+	require.EqualError(t, err, "backend down")
+	require.Empty(t, fake.Patches("applicant-1"))
+}
+
+func TestFakeHandlerServesClient(t *testing.T) {
+	fake := NewFake()
+	server := httptest.NewServer(fake.Handler())
+	defer server.Close()
+	client := NewClient(config.ProfileConfig{BaseURL: server.URL})
+
+	_, err := client.AttachWorkflow(context.Background(), "applicant-1", "workflow-1", "run-1")
+
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{map[string]interface{}{"workflow_id": "workflow-1", "run_id": "run-1"}}, fake.Patches("applicant-1"))
+
+	fake.Err = errors.New("backend down")
+	_, err = client.CreateTeacher(context.Background(), "applicant-1")
+	var statusErr *StatusError
+	require.True(t, errors.As(err, &statusErr))
+	require.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+
+	response, err := http.Post(server.URL+profilePath, "application/json", strings.NewReader("{}"))
+	require.NoError(t, err)
+	response.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, response.StatusCode)
+}
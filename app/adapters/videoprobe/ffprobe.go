@@ -0,0 +1,75 @@
+// app/adapters/videoprobe/ffprobe.go
+package videoprobe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/worker/workflows"
+)
+
+// FFprobe recognizes the container of a video from its header, like
+// workflows.HeaderProbe, and runs ffprobe for durations the header does not
+// record, e.g. of WebM and Matroska files. The video is spooled to a
+// temporary file, as ffprobe needs to seek in MP4 files.
+type FFprobe struct {
+	path string
+}
+
+// NewFFprobe creates an FFprobe running the ffprobe binary at path.
+func NewFFprobe(path string) *FFprobe {
+	return &FFprobe{path: path}
+}
+
+// Probe probes the video read from r.
+func (p *FFprobe) Probe(ctx context.Context, r io.Reader) (workflows.VideoInfo, error) {
+	file, err := ioutil.TempFile("", "probe-")
+	if err != nil {
+		return workflows.VideoInfo{}, &workflows.ProbeError{Err: err}
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if _, err := io.Copy(file, r); err != nil {
+		return workflows.VideoInfo{}, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return workflows.VideoInfo{}, &workflows.ProbeError{Err: err}
+	}
+
+	video, err := workflows.HeaderProbe{}.Probe(ctx, file)
+	if err != nil || video.Duration > 0 {
+		return video, err
+	}
+	video.Duration, err = p.duration(ctx, file.Name())
+	return video, err
+}
+
+// duration runs ffprobe for the duration of the file at name.
+func (p *FFprobe) duration(ctx context.Context, name string) (time.Duration, error) {
+	output, err := exec.CommandContext(ctx, p.path,
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		name,
+	).Output()
+	if err != nil {
+		return 0, &workflows.ProbeError{Err: err}
+	}
+	value := strings.TrimSpace(string(output))
+	if value == "N/A" {
+		return 0, nil
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, &workflows.ProbeError{Err: fmt.Errorf("unexpected ffprobe output %q", value)}
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
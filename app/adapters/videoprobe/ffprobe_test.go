@@ -0,0 +1,58 @@
+package videoprobe
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/worker/workflows"
+
+	"github.com/stretchr/testify/require"
+)
+
+var webm = append([]byte{0x1a, 0x45, 0xdf, 0xa3, 0x9f, 0x42, 0x82, 0x84}, []byte("webm, then the clusters")...)
+
+// fakeFFprobe writes a script standing in for ffprobe that prints output.
+func fakeFFprobe(t *testing.T, output string) string {
+	path := filepath.Join(t.TempDir(), "ffprobe")
+	require.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\necho '"+output+"'\n"), 0755))
+	return path
+}
+
+func TestFFprobeReadsDurationsTheHeaderLacks(t *testing.T) {
+	video, err := NewFFprobe(fakeFFprobe(t, "12.500000")).Probe(context.Background(), bytes.NewReader(webm))
+
+	require.NoError(t, err)
+	require.Equal(t, workflows.VideoInfo{Container: "webm", Duration: 12500 * time.Millisecond}, video)
+}
+
+func TestFFprobeLeavesUnknownDurationsZero(t *testing.T) {
+	video, err := NewFFprobe(fakeFFprobe(t, "N/A")).Probe(context.Background(), bytes.NewReader(webm))
+
+	require.NoError(t, err)
+	require.Zero(t, video.Duration)
+}
+
+func TestFFprobeFailuresAreProbeErrors(t *testing.T) {
+	for name, path := range map[string]string{
+		"missing binary":    filepath.Join(t.TempDir(), "ffprobe"),
+		"unexpected output": fakeFFprobe(t, "duration unknown"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewFFprobe(path).Probe(context.Background(), bytes.NewReader(webm))
+
+			var probeErr *workflows.ProbeError
+			require.True(t, errors.As(err, &probeErr), "%v", err)
+		})
+	}
+}
+
+func TestFFprobeRejectsUnknownContainers(t *testing.T) {
+	_, err := NewFFprobe(fakeFFprobe(t, "1.0")).Probe(context.Background(), bytes.NewReader([]byte("not a video")))
+
+	require.EqualError(t, err, "unknown container")
+}
@@ -3,6 +3,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
@@ -14,11 +15,93 @@ type CadenceConfig struct {
 	HostPort string
 }
 
+// ProfileConfig points the worker at the applicant-profile backend.
+type ProfileConfig struct {
+	BaseURL string
+	// AuthHeader is sent as the Authorization header when set.
+	AuthHeader string
+	Timeout    time.Duration
+}
+
+// NotifierConfig points the worker at the webhook reminders are posted to.
+type NotifierConfig struct {
+	// WebhookURL receives each reminder as JSON. Reminders are only logged
+	// when it is empty.
+	WebhookURL string
+	// AuthHeader is sent as the Authorization header when set.
+	AuthHeader string
+	Timeout    time.Duration
+}
+
+// ActivityProfileConfig is a named set of activity options.
+type ActivityProfileConfig struct {
+	ScheduleToStartTimeout time.Duration
+	StartToCloseTimeout    time.Duration
+	HeartbeatTimeout       time.Duration
+	Retry                  *RetryConfig
+}
+
+// RetryConfig is the retry policy of an activity profile.
+type RetryConfig struct {
+	InitialInterval          time.Duration
+	BackoffCoefficient       float64
+	MaximumInterval          time.Duration
+	ExpirationInterval       time.Duration
+	MaximumAttempts          int32
+	NonRetriableErrorReasons []string
+}
+
+// ScoringConfig is the rubric SOP and CET submissions are scored with.
+type ScoringConfig struct {
+	Keywords []string
+	MinWords int
+	MaxWords int
+	// AnswerKey maps CET questions to their answers.
+	AnswerKey map[string]string
+}
+
+// ObjectStoreConfig configures the store lesson videos are uploaded to.
+type ObjectStoreConfig struct {
+	// Dir is the root directory of the local store.
+	Dir string
+	// BaseURL is the URL of the HTTP server upload targets point at.
+	BaseURL string
+	// Secret signs upload targets.
+	Secret       string
+	UploadExpiry time.Duration
+	// MaxSize caps the size of an upload in bytes when set.
+	MaxSize int64
+}
+
 type AppConfig struct {
 	Env            string
 	WorkerTaskList string
-	Cadence        CadenceConfig
-	Logger         *zap.Logger
+	JourneysPath   string
+	AgreementsPath string
+	// WorkflowIDReusePolicy names the client.WorkflowIDReusePolicy used when
+	// starting journeys, e.g. "AllowDuplicateFailedOnly".
+	WorkflowIDReusePolicy string
+	Cadence               CadenceConfig
+	Profile               ProfileConfig
+	Notifier              NotifierConfig
+	// ActivityProfiles are named activity options; Activities maps activity
+	// names to the profile they run with.
+	ActivityProfiles map[string]ActivityProfileConfig
+	Activities       map[string]string
+	ObjectStore      ObjectStoreConfig
+	// FFprobePath is the ffprobe binary uploaded videos are probed with when
+	// set. Without it the durations of WebM and Matroska videos are unknown.
+	FFprobePath string
+	// Scoring replaces the built-in scoring rubric when set.
+	Scoring *ScoringConfig
+	// ReviewSearchAttributes makes review steps record their task in search
+	// attributes, which /api/reviews then lists reviews by. It needs advanced
+	// visibility on the cluster.
+	ReviewSearchAttributes bool
+	// SubmitWaitTimeout bounds how long a submission with wait=true waits
+	// for its signal to be applied. It defaults to ten seconds.
+	SubmitWaitTimeout time.Duration
+	Logger            *zap.Logger
 }
 
 // Setup setup the config for the code run
@@ -0,0 +1,54 @@
+// app/httpserver/agreements.go
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/worker/workflows"
+)
+
+// getAgreement handles GET /api/agreements/{name}. It returns the latest
+// version of the agreement, or the one given by the version query parameter.
+func (h *Service) getAgreement(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" {
+		name := strings.TrimPrefix(r.URL.Path, "/api/agreements/")
+		version := 0
+		if v := r.URL.Query().Get("version"); v != "" {
+			var err error
+			if version, err = strconv.Atoi(v); err != nil {
+				http.Error(w, "Invalid version!", http.StatusBadRequest)
+				return
+			}
+		}
+
+		template, ok := workflows.FindAgreement(h.agreements, name, version)
+		if !ok {
+			http.Error(w, "Unknown agreement "+name+"!", http.StatusNotFound)
+			return
+		}
+
+		js, _ := json.Marshal(template)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(js)
+	} else {
+		_, _ = w.Write([]byte("Invalid Method!" + r.Method))
+	}
+}
+
+// clientInfo describes the client of a request, taking the IP from the first
+// X-Forwarded-For entry when the server runs behind a proxy.
+func clientInfo(r *http.Request) *workflows.ClientInfo {
+	ip := strings.TrimSpace(strings.Split(r.Header.Get("X-Forwarded-For"), ",")[0])
+	if ip == "" {
+		ip = r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			ip = host
+		}
+	}
+	return &workflows.ClientInfo{IP: ip, UserAgent: r.UserAgent(), Time: time.Now().UTC()}
+}
@@ -0,0 +1,206 @@
+// app/httpserver/documents.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/adapters/objectstore"
+	"github.com/BhanuChandraAraveti/cadence-example/app/worker/workflows"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/client"
+	"go.uber.org/zap"
+)
+
+// queryDocuments returns the documents of the documents step an execution
+// waits on, or none when it is not waiting on documents.
+func (h *Service) queryDocuments(workflowID string, runID string) ([]workflows.DocumentStatus, error) {
+	resp, err := h.cadenceAdapter.CadenceClient.QueryWorkflowWithOptions(context.Background(), &client.QueryWorkflowWithOptionsRequest{
+		WorkflowID:            workflowID,
+		RunID:                 runID,
+		QueryType:             "documents",
+		QueryConsistencyLevel: s.QueryConsistencyLevelStrong.Ptr(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var documents []workflows.DocumentStatus
+	err = resp.QueryResult.Get(&documents)
+	return documents, err
+}
+
+// openDocument decodes a document request and returns it with the document
+// it is for. It writes the error response and returns ok false when the
+// execution is not waiting on the requested document.
+func (h *Service) openDocument(w http.ResponseWriter, r *http.Request) (workflows.DocumentSignal, workflows.DocumentStatus, bool) {
+	data := workflows.DocumentSignal{}
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return data, workflows.DocumentStatus{}, false
+	}
+	if data.WorkflowId == "" || data.Action == "" || data.Type == "" {
+		http.Error(w, "Missing workflowId, action or type!", http.StatusBadRequest)
+		return data, workflows.DocumentStatus{}, false
+	}
+
+	documents, err := h.queryDocuments(data.WorkflowId, data.RunId)
+	if err != nil {
+		http.Error(w, "Error getting documents!", http.StatusBadRequest)
+		return data, workflows.DocumentStatus{}, false
+	}
+	for _, document := range documents {
+		if document.Action == data.Action && document.Type == data.Type {
+			return data, document, true
+		}
+	}
+	writeDocumentConflict(w, "No open document "+data.Type+" for "+data.Action, nil)
+	return data, workflows.DocumentStatus{}, false
+}
+
+// listDocuments handles GET /api/documents?workflowId=&runId=. It lists the
+// documents of the documents step the execution waits on.
+func (h *Service) listDocuments(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" {
+		workflowID := r.URL.Query().Get("workflowId")
+		if workflowID == "" {
+			http.Error(w, "Missing workflowId!", http.StatusBadRequest)
+			return
+		}
+		documents, err := h.queryDocuments(workflowID, r.URL.Query().Get("runId"))
+		if err != nil {
+			http.Error(w, "Error getting documents!", http.StatusBadRequest)
+			return
+		}
+		if documents == nil {
+			documents = []workflows.DocumentStatus{}
+		}
+
+		js, _ := json.Marshal(documents)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(js)
+	} else {
+		_, _ = w.Write([]byte("Invalid Method!" + r.Method))
+	}
+}
+
+// requestDocumentUpload handles POST /api/documents/upload. It returns a
+// pre-signed target the applicant uploads a document to.
+func (h *Service) requestDocumentUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		data, document, ok := h.openDocument(w, r)
+		if !ok {
+			return
+		}
+		if document.Status == workflows.DocumentVerified {
+			writeDocumentConflict(w, "Document "+data.Type+" already verified", &document)
+			return
+		}
+
+		key := workflows.DocumentPrefix(data.WorkflowId, data.Action, data.Type) + strconv.FormatInt(time.Now().UnixNano(), 10)
+		target, err := h.objectStore.PresignUpload(key)
+		if err != nil {
+			h.logger.Error("Presign upload failed.", zap.Error(err))
+			http.Error(w, "Error creating upload!", http.StatusBadRequest)
+			return
+		}
+
+		js, _ := json.Marshal(target)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(js)
+	} else {
+		_, _ = w.Write([]byte("Invalid Method!" + r.Method))
+	}
+}
+
+// completeDocumentUpload handles POST /api/documents/complete. Once the
+// document is in the store it sends the document-uploaded signal.
+func (h *Service) completeDocumentUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		data, document, ok := h.openDocument(w, r)
+		if !ok {
+			return
+		}
+		if document.Status == workflows.DocumentVerified {
+			writeDocumentConflict(w, "Document "+data.Type+" already verified", &document)
+			return
+		}
+		if !strings.HasPrefix(data.Key, workflows.DocumentPrefix(data.WorkflowId, data.Action, data.Type)) {
+			http.Error(w, "Invalid key!", http.StatusBadRequest)
+			return
+		}
+		_, err := h.objectStore.Stat(context.Background(), data.Key)
+		if errors.Is(err, objectstore.ErrNotFound) {
+			http.Error(w, "Nothing uploaded to "+data.Key+"!", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			h.logger.Error("Stat upload failed.", zap.Error(err))
+			http.Error(w, "Error getting upload!", http.StatusBadRequest)
+			return
+		}
+
+		h.signalDocument(w, workflows.DocumentUploadedSignalName, data)
+	} else {
+		_, _ = w.Write([]byte("Invalid Method!" + r.Method))
+	}
+}
+
+// documentDecisionHandler returns the handler sending a reviewer decision on
+// an uploaded document: verify-document or reject-document.
+func (h *Service) documentDecisionHandler(signalName string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			data, document, ok := h.openDocument(w, r)
+			if !ok {
+				return
+			}
+			if data.Reviewer == "" {
+				http.Error(w, "Missing reviewer!", http.StatusBadRequest)
+				return
+			}
+			if document.Status != workflows.DocumentUploaded {
+				writeDocumentConflict(w, "Document "+data.Type+" is "+document.Status, &document)
+				return
+			}
+
+			h.signalDocument(w, signalName, data)
+		} else {
+			_, _ = w.Write([]byte("Invalid Method!" + r.Method))
+		}
+	}
+}
+
+func (h *Service) signalDocument(w http.ResponseWriter, signalName string, data workflows.DocumentSignal) {
+	err := h.cadenceAdapter.CadenceClient.SignalWorkflow(context.Background(), data.WorkflowId, data.RunId, signalName, data)
+	if err != nil {
+		http.Error(w, "Error signaling workflow!", http.StatusBadRequest)
+		return
+	}
+
+	h.logger.Info("Signaled document!", zap.String("WorkflowId", data.WorkflowId), zap.String("Signal", signalName), zap.String("Type", data.Type))
+
+	js, _ := json.Marshal("Success")
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(js)
+}
+
+// documentConflict is returned with a 409 when a document request does not
+// fit the document's status.
+type documentConflict struct {
+	Error    string                    `json:"error"`
+	Document *workflows.DocumentStatus `json:"document,omitempty"`
+}
+
+func writeDocumentConflict(w http.ResponseWriter, message string, document *workflows.DocumentStatus) {
+	js, _ := json.Marshal(documentConflict{Error: message, Document: document})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	_, _ = w.Write(js)
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/BhanuChandraAraveti/cadence-example/app/adapters/cadenceAdapter"
+	"github.com/BhanuChandraAraveti/cadence-example/app/adapters/objectstore"
 	"github.com/BhanuChandraAraveti/cadence-example/app/config"
 	"github.com/BhanuChandraAraveti/cadence-example/app/worker/workflows"
 
@@ -22,9 +23,18 @@ import (
 type Service struct {
 	cadenceAdapter *cadenceAdapter.CadenceAdapter
 	logger         *zap.Logger
+	journeys       []workflows.JourneyDefinition
+	registry       map[string]workflowEntry
+	objectStore    workflows.ObjectStore
+	agreements     []workflows.AgreementTemplate
+	// reviewSearchAttributes lists reviews by their search attributes.
+	reviewSearchAttributes bool
+	// submitWaitTimeout bounds how long a submission with wait=true waits
+	// for its signal to be applied; zero means defaultSubmitWaitTimeout.
+	submitWaitTimeout time.Duration
 }
 
-func (h *Service) triggerSignup(w http.ResponseWriter, r *http.Request) {
+func (h *Service) parentStart(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "POST" {
 		applicantID := r.URL.Query().Get("applicant_id")
 		h.logger.Info("####### flow!", zap.String("applicantId", applicantID))
@@ -33,13 +43,13 @@ func (h *Service) triggerSignup(w http.ResponseWriter, r *http.Request) {
 			TaskList:                     workflows.TaskListName,
 			ExecutionStartToCloseTimeout: time.Hour * 24,
 		}
-		execution, err := h.cadenceAdapter.CadenceClient.StartWorkflow(context.Background(), wo, workflows.SignupWorkflow, applicantID)
+		execution, err := h.cadenceAdapter.CadenceClient.StartWorkflow(context.Background(), wo, workflows.SampleParentWorkflow)
 		if err != nil {
 			http.Error(w, "Error starting workflow!", http.StatusBadRequest)
 			return
 		}
 
-		h.logger.Info("Started work flow!", zap.String("WorkflowId", execution.ID), zap.String("RunId", execution.RunID))
+		h.logger.Info("Parent Started work flow!", zap.String("WorkflowId", execution.ID), zap.String("RunId", execution.RunID))
 		js, _ := json.Marshal(execution)
 		w.Header().Set("Content-Type", "application/json")
 		_, _ = w.Write(js)
@@ -49,20 +59,35 @@ func (h *Service) triggerSignup(w http.ResponseWriter, r *http.Request) {
 }
 
 
-func (h *Service) triggerTeacherJourney(w http.ResponseWriter, r *http.Request) {
+func (h *Service) LastCompletedActivity(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "POST" {
-		wo := client.StartWorkflowOptions{
-			TaskList:                     workflows.TaskListName,
-			ExecutionStartToCloseTimeout: time.Hour * 24,
-		}
-		execution, err := h.cadenceAdapter.CadenceClient.StartWorkflow(context.Background(), wo, workflows.TeacherJourneyWorkflow)
-		if err != nil {
-			http.Error(w, "Error starting teacher journey workflow!", http.StatusBadRequest)
-			return
+		workflowId := r.URL.Query().Get("workflowId")
+		runId := r.URL.Query().Get("runId")
+		
+
+		// To iterate all events,
+		var isLongPoll bool
+		iter := h.cadenceAdapter.CadenceClient.GetWorkflowHistory(context.Background(), workflowId, runId, isLongPoll, s.HistoryEventFilterTypeAllEvent)
+		events := []*s.HistoryEvent{}
+		h.logger.Info("$$$$$", zap.Any("iter",iter), zap.Any("events", events))
+		//var lastActivity string
+		for iter.HasNext() {
+			event, err := iter.Next()
+			if err != nil {
+				return
+			}
+			events = append(events, event)
+			eventName := event.GetEventType().String()
+			h.logger.Info("Task Ids", zap.String("Event name", eventName))
+			if *event.EventType == s.EventTypeActivityTaskCompleted {
+				// Store the name of the last completed activity.
+				//lastActivity = event.ActivityTaskCompletedEventAttributes.ActivityType.Name
+			}
 		}
+		h.logger.Info("******", zap.String("WorkflowId", workflowId))
+
+		js, _ := json.Marshal("Success")
 
-		h.logger.Info("Started teacher journey flow!", zap.String("WorkflowId", execution.ID), zap.String("RunId", execution.RunID))
-		js, _ := json.Marshal(execution)
 		w.Header().Set("Content-Type", "application/json")
 		_, _ = w.Write(js)
 	} else {
@@ -70,43 +95,24 @@ func (h *Service) triggerTeacherJourney(w http.ResponseWriter, r *http.Request)
 	}
 }
 
-
-func (h *Service) triggerOrientation(w http.ResponseWriter, r *http.Request) {
+func (h *Service) signalHelloWorld(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "POST" {
-		applicantID := r.URL.Query().Get("applicant_id")
-		h.logger.Info("####### flow!", zap.String("applicantId", applicantID))
-
-		wo := client.StartWorkflowOptions{
-			TaskList:                     workflows.TaskListName,
-			ExecutionStartToCloseTimeout: time.Hour * 24,
-		}
-		execution, err := h.cadenceAdapter.CadenceClient.StartWorkflow(context.Background(), wo, workflows.OrientationWorkflow, applicantID)
+		workflowId := r.URL.Query().Get("workflowId")
+		age, err := strconv.Atoi(r.URL.Query().Get("age"))
 		if err != nil {
-			http.Error(w, "Error starting orientation workflow!", http.StatusBadRequest)
-			return
+			h.logger.Error("Failed to parse age from request!")
 		}
 
-		resp, err := h.cadenceAdapter.CadenceClient.QueryWorkflowWithOptions(context.Background(), &client.QueryWorkflowWithOptionsRequest{
-			WorkflowID:            execution.ID,
-			RunID:                 execution.RunID,
-			QueryType:             "state",
-			QueryConsistencyLevel: s.QueryConsistencyLevelStrong.Ptr(),
-		})
-
+		err = h.cadenceAdapter.CadenceClient.SignalWorkflow(context.Background(), workflowId, "", workflows.SignalName, age)
 		if err != nil {
-			http.Error(w, "Error starting orientation workflow!", http.StatusBadRequest)
+			http.Error(w, "Error signaling workflow!", http.StatusBadRequest)
 			return
 		}
 
-		queryResult:= workflows.Response{}
-		resp.QueryResult.Get(&queryResult.WorkflowState)
-		queryResult.WorkflowID = execution.ID
-		queryResult.RunID = execution.RunID
-		h.logger.Info("Started orientation workflow!", zap.String("WorkflowId", execution.ID), zap.String("RunId", execution.RunID))
-		h.logger.Info("Query Result", zap.Any("hasValue", queryResult))
-		//execution.AppendObject("query", resp)
+		h.logger.Info("Signaled work flow with the following params!", zap.String("WorkflowId", workflowId), zap.Int("Age", age))
+
+		js, _ := json.Marshal("Success")
 
-		js, _ := json.Marshal(execution)
 		w.Header().Set("Content-Type", "application/json")
 		_, _ = w.Write(js)
 	} else {
@@ -114,97 +120,82 @@ func (h *Service) triggerOrientation(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (h *Service) triggerSetup(w http.ResponseWriter, r *http.Request) {
-	if r.Method == "POST" {
-		applicantID := r.URL.Query().Get("applicant_id")
-		h.logger.Info("####### flow!", zap.String("applicantId", applicantID))
-
-		wo := client.StartWorkflowOptions{
-			TaskList:                     workflows.TaskListName,
-			ExecutionStartToCloseTimeout: time.Hour * 24,
-		}
-		execution, err := h.cadenceAdapter.CadenceClient.StartWorkflow(context.Background(), wo, workflows.SetupWorkflow, applicantID)
-		if err != nil {
-			http.Error(w, "Error starting Setup workflow!", http.StatusBadRequest)
-			return
-		}
-
-		resp, err := h.cadenceAdapter.CadenceClient.QueryWorkflowWithOptions(context.Background(), &client.QueryWorkflowWithOptionsRequest{
-			WorkflowID:            execution.ID,
-			RunID:                 execution.RunID,
-			QueryType:             "state",
-			QueryConsistencyLevel: s.QueryConsistencyLevelStrong.Ptr(),
-		})
 
-		if err != nil {
-			http.Error(w, "Error starting Setup workflow!", http.StatusBadRequest)
-			return
-		}
+type Mystruct struct {
+	WorkflowId string `json:"workflowId"`
+	RunId string `json:"runId"`
+	Payload interface{} `json:"payload"`
+	ApplicantId string `json:"applicantId"`
+	Action string `json:"action,omitempty"`
+	Client *workflows.ClientInfo `json:"client,omitempty"`
+	// Journey is the type of the applicant's journey a submission without a
+	// workflowId is for, onboarding by default.
+	Journey string `json:"journey,omitempty"`
+}
 
-		queryResult:= workflows.Response{}
-		resp.QueryResult.Get(&queryResult.WorkflowState)
-		queryResult.WorkflowID = execution.ID
-		queryResult.RunID = execution.RunID
-		h.logger.Info("Started Setup workflow!", zap.String("WorkflowId", execution.ID), zap.String("RunId", execution.RunID))
-		h.logger.Info("Query Result", zap.Any("hasValue", queryResult))
-		//execution.AppendObject("query", resp)
+// journeyPosition tells which journey, version and step an execution is on.
+type journeyPosition struct {
+	Journey string
+	Version int
+	Current workflows.WorkflowStep
+	Dormant bool
+}
 
-		js, _ := json.Marshal(queryResult)
-		w.Header().Set("Content-Type", "application/json")
-		_, _ = w.Write(js)
-	} else {
-		_, _ = w.Write([]byte("Invalid Method!" + r.Method))
+// positionOf returns the position of an execution from its status tree. The
+// step it waits on is in its deepest current stage; children with a workflow
+// ID of their own are other executions.
+func positionOf(status workflows.JourneyStatus) journeyPosition {
+	node := status
+	for len(node.Children) > 0 && node.Children[len(node.Children)-1].WorkflowID == "" {
+		node = node.Children[len(node.Children)-1]
+	}
+	return journeyPosition{
+		Journey: status.Journey,
+		Version: status.Version,
+		Current: node.Current,
+		Dormant: node.Dormant,
 	}
 }
 
-func (h *Service) triggerOnboarding(w http.ResponseWriter, r *http.Request) {
-	if r.Method == "POST" {
-		applicantID := r.URL.Query().Get("applicant_id")
-		h.logger.Info("####### flow!", zap.String("applicantId", applicantID))
-
-		wo := client.StartWorkflowOptions{
-			TaskList:                     workflows.TaskListName,
-			ExecutionStartToCloseTimeout: time.Hour * 24,
-		}
-		execution, err := h.cadenceAdapter.CadenceClient.StartWorkflow(context.Background(), wo, workflows.OnboardingWorkflow, applicantID)
-		if err != nil {
-			http.Error(w, "Error starting Onboarding workflow!", http.StatusBadRequest)
-			return
-		}
+// conflictResponse is returned with a 409 when a submission does not answer
+// the step the execution is waiting on.
+type conflictResponse struct {
+	Error   string                 `json:"error"`
+	Current workflows.WorkflowStep `json:"current"`
+}
 
-		resp, err := h.cadenceAdapter.CadenceClient.QueryWorkflowWithOptions(context.Background(), &client.QueryWorkflowWithOptionsRequest{
-			WorkflowID:            execution.ID,
-			RunID:                 execution.RunID,
-			QueryType:             "state",
-			QueryConsistencyLevel: s.QueryConsistencyLevelStrong.Ptr(),
-		})
+func writeConflict(w http.ResponseWriter, message string, current workflows.WorkflowStep) {
+	js, _ := json.Marshal(conflictResponse{Error: message, Current: current})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	_, _ = w.Write(js)
+}
 
-		if err != nil {
-			http.Error(w, "Error starting Onboarding workflow!", http.StatusBadRequest)
-			return
-		}
+// submitResponse is returned for a submission: the execution waiting on the
+// applicant next and its current step. Submissions that wait for the signal to
+// be applied also get the status tree, and Pending when the wait timed out.
+type submitResponse struct {
+	workflows.Execution
+	Current *workflows.WorkflowStep  `json:"current,omitempty"`
+	Status  *workflows.JourneyStatus `json:"status,omitempty"`
+	Pending bool                     `json:"pending,omitempty"`
+}
 
-		queryResult:= workflows.Response{}
-		resp.QueryResult.Get(&queryResult.WorkflowState)
-		queryResult.WorkflowID = execution.ID
-		queryResult.RunID = execution.RunID
-		h.logger.Info("Started Onboarding workflow!", zap.String("WorkflowId", execution.ID), zap.String("RunId", execution.RunID))
-		h.logger.Info("Query Result", zap.Any("hasValue", queryResult))
-		//execution.AppendObject("query", resp)
+// How long by default and how often a submission with wait=true polls the
+// status tree for the signal to be applied.
+const (
+	defaultSubmitWaitTimeout = 10 * time.Second
+	submitPollInterval       = 200 * time.Millisecond
+)
 
-		js, _ := json.Marshal(queryResult)
-		w.Header().Set("Content-Type", "application/json")
-		_, _ = w.Write(js)
-	} else {
-		_, _ = w.Write([]byte("Invalid Method!" + r.Method))
-	}
+// submitWait tells whether a submission waits for its signal to be applied.
+func submitWait(r *http.Request) bool {
+	wait, _ := strconv.ParseBool(r.URL.Query().Get("wait"))
+	return wait
 }
 
-
-func (h *Service) getStatusSingle(w http.ResponseWriter, r *http.Request) {
+func (h *Service) submit(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "POST" {
-		h.logger.Info("$$$$$")
-
 		data := Mystruct{}
 		err := json.NewDecoder(r.Body).Decode(&data)
 		if err != nil {
@@ -212,217 +203,276 @@ func (h *Service) getStatusSingle(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		workflowID := data.WorkflowId
-		runID := data.RunId
-		h.logger.Info(workflowID)
-		h.logger.Info("payload", zap.Any("data", data))
+		data.Client = clientInfo(r)
 
-		resp, err := h.cadenceAdapter.CadenceClient.QueryWorkflowWithOptions(context.Background(), &client.QueryWorkflowWithOptionsRequest{
-			WorkflowID:            workflowID,
-			RunID:                 runID,
-			QueryType:             "state",
-			QueryConsistencyLevel: s.QueryConsistencyLevelStrong.Ptr(),
-		})
+		h.logger.Info("payload", zap.Any("data", data))
 
-		if err != nil {
-			http.Error(w, "Error getting status workflow!", http.StatusBadRequest)
+		// Submissions may name the applicant or a parent execution; they are
+		// delivered to the execution waiting on the applicant.
+		root, ok := rootExecution(data.WorkflowId, data.RunId, data.ApplicantId, data.Journey)
+		if !ok {
+			http.Error(w, "Missing workflowId or applicantId!", http.StatusBadRequest)
 			return
 		}
-
-		queryResult:= workflows.Response2{}
-		resp.QueryResult.Get(&queryResult.WorkflowData)
-		queryResult.WorkflowID = workflowID
-		queryResult.RunID = runID
-		h.logger.Info("Query Result", zap.Any("hasValue", queryResult))
-		//execution.AppendObject("query", resp)
-
-		js, _ := json.Marshal(queryResult)
-		w.Header().Set("Content-Type", "application/json")
-		_, _ = w.Write(js)
-	} else {
-		_, _ = w.Write([]byte("Invalid Method!" + r.Method))
-	}
-}
-
-func (h *Service) getStatus(w http.ResponseWriter, r *http.Request) {
-	if r.Method == "POST" {
-		h.logger.Info("$$$$$")
-
-		data := Mystruct{}
-		err := json.NewDecoder(r.Body).Decode(&data)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
+		// The first submission naming only the applicant starts the journey.
+		// Closed executions are left alone and answer like running ones.
+		if data.WorkflowId == "" {
+			_, _, err := h.findExecution(root.WorkflowID)
+			if _, notFound := err.(*s.EntityNotExistsError); notFound {
+				h.submitWithStart(r.Context(), w, root, data, submitWait(r))
+				return
+			}
+			if err != nil {
+				h.logger.Error("Describe workflow failed.", zap.String("WorkflowId", root.WorkflowID), zap.Error(err))
+				http.Error(w, "Error getting journey workflow!", http.StatusBadRequest)
+				return
+			}
 		}
 
-		workflowID := data.WorkflowId
-		runID := data.RunId
-		h.logger.Info(workflowID)
-		h.logger.Info("payload", zap.Any("data", data))
-
-		resp, err := h.cadenceAdapter.CadenceClient.QueryWorkflowWithOptions(context.Background(), &client.QueryWorkflowWithOptionsRequest{
-			WorkflowID:            workflowID,
-			RunID:                 runID,
-			QueryType:             "state",
-			QueryConsistencyLevel: s.QueryConsistencyLevelStrong.Ptr(),
-		})
-
+		active, err := h.queryActive(root)
 		if err != nil {
 			http.Error(w, "Error getting status workflow!", http.StatusBadRequest)
 			return
 		}
+		position := positionOf(active)
+		if position.Journey == "" {
+			if !checkLegacyPosition(w, position, data) {
+				return
+			}
+		} else if !h.checkPosition(w, position, data) {
+			return
+		}
 
-		queryResult:= workflows.Response{}
-		resp.QueryResult.Get(&queryResult.WorkflowState)
-		queryResult.WorkflowID = workflowID
-		queryResult.RunID = runID
-		h.logger.Info("Query Result", zap.Any("hasValue", queryResult))
-		//execution.AppendObject("query", resp)
-
-
-		resp, err = h.cadenceAdapter.CadenceClient.QueryWorkflowWithOptions(context.Background(), &client.QueryWorkflowWithOptionsRequest{
-			WorkflowID:            queryResult.Execution.WorkflowID,
-			RunID:                 queryResult.Execution.RunID,
-			QueryType:             "state",
-			QueryConsistencyLevel: s.QueryConsistencyLevelStrong.Ptr(),
-		})
-
+		data.WorkflowId = active.WorkflowID
+		data.RunId = active.RunID
+		err = h.cadenceAdapter.CadenceClient.SignalWorkflow(context.Background(), active.WorkflowID, active.RunID, workflows.SignalName, data)
 		if err != nil {
-			http.Error(w, "Error getting status workflow!", http.StatusBadRequest)
+			http.Error(w, "Error signaling workflow!", http.StatusBadRequest)
 			return
 		}
 
-		childQueryResult:= workflows.Response{}
-		resp.QueryResult.Get(&childQueryResult.WorkflowState)
-		childQueryResult.WorkflowID = workflowID
-		childQueryResult.RunID = runID
-		h.logger.Info("Child Query Result", zap.Any("hasValue", childQueryResult))
+		h.logger.Info("Signaled work flow with the following params!", zap.String("WorkflowId", active.WorkflowID), zap.String("Action", data.Action))
 
-		js, _ := json.Marshal(queryResult)
-		w.Header().Set("Content-Type", "application/json")
-		_, _ = w.Write(js)
+		h.writeSubmitted(r.Context(), w, root, active.Execution, submitWait(r), active.Sequence)
 	} else {
 		_, _ = w.Write([]byte("Invalid Method!" + r.Method))
 	}
 }
 
-func (h *Service) parentStart(w http.ResponseWriter, r *http.Request) {
-	if r.Method == "POST" {
-		applicantID := r.URL.Query().Get("applicant_id")
-		h.logger.Info("####### flow!", zap.String("applicantId", applicantID))
-
-		wo := client.StartWorkflowOptions{
-			TaskList:                     workflows.TaskListName,
-			ExecutionStartToCloseTimeout: time.Hour * 24,
-		}
-		execution, err := h.cadenceAdapter.CadenceClient.StartWorkflow(context.Background(), wo, workflows.SampleParentWorkflow)
-		if err != nil {
-			http.Error(w, "Error starting workflow!", http.StatusBadRequest)
-			return
-		}
-
-		h.logger.Info("Parent Started work flow!", zap.String("WorkflowId", execution.ID), zap.String("RunId", execution.RunID))
-		js, _ := json.Marshal(execution)
-		w.Header().Set("Content-Type", "application/json")
-		_, _ = w.Write(js)
-	} else {
-		_, _ = w.Write([]byte("Invalid Method!" + r.Method))
+// checkPosition checks that a submission answers the step a journey execution
+// is waiting on. It writes the error response and returns false when it does
+// not.
+func (h *Service) checkPosition(w http.ResponseWriter, position journeyPosition, data Mystruct) bool {
+	if data.Action == "" {
+		http.Error(w, "Missing action!", http.StatusBadRequest)
+		return false
+	}
+	if status := position.Current.Status; status == workflows.StatusCompleted || status == workflows.StatusSkipped {
+		writeConflict(w, "Journey already completed", position.Current)
+		return false
+	}
+	if position.Current.Status == workflows.StatusRejected {
+		writeConflict(w, "Journey rejected", position.Current)
+		return false
+	}
+	if position.Current.Status == workflows.StatusExpired && !position.Dormant {
+		writeConflict(w, "Journey expired", position.Current)
+		return false
 	}
+	if data.Action != position.Current.Action {
+		writeConflict(w, "Submission for "+data.Action+" while waiting on "+position.Current.Action, position.Current)
+		return false
+	}
+	if step, ok := workflows.FindStep(h.journeys, position.Journey, position.Version, position.Current.Action); ok {
+		return checkStep(w, step, data, position.Current)
+	}
+	return true
 }
 
+// checkLegacyPosition checks a submission to an execution without a journey
+// definition: one started before journeys ran on the step runner, or the
+// onboarding parent while none of its children is waiting. Their screens take
+// any payload and their clients may leave out the action, but a submission
+// naming one has to answer the current step, and one without a current step
+// has nothing to answer.
+func checkLegacyPosition(w http.ResponseWriter, position journeyPosition, data Mystruct) bool {
+	if position.Current.Action == "" {
+		writeConflict(w, "No step waiting on a submission", position.Current)
+		return false
+	}
+	if data.Action != "" && data.Action != position.Current.Action {
+		writeConflict(w, "Submission for "+data.Action+" while waiting on "+position.Current.Action, position.Current)
+		return false
+	}
+	return true
+}
 
-func (h *Service) LastCompletedActivity(w http.ResponseWriter, r *http.Request) {
-	if r.Method == "POST" {
-		workflowId := r.URL.Query().Get("workflowId")
-		runId := r.URL.Query().Get("runId")
-		
-
-		// To iterate all events,
-		var isLongPoll bool
-		iter := h.cadenceAdapter.CadenceClient.GetWorkflowHistory(context.Background(), workflowId, runId, isLongPoll, s.HistoryEventFilterTypeAllEvent)
-		events := []*s.HistoryEvent{}
-		h.logger.Info("$$$$$", zap.Any("iter",iter), zap.Any("events", events))
-		//var lastActivity string
-		for iter.HasNext() {
-			event, err := iter.Next()
-			if err != nil {
-				return
-			}
-			events = append(events, event)
-			eventName := event.GetEventType().String()
-			h.logger.Info("Task Ids", zap.String("Event name", eventName))
-			if *event.EventType == s.EventTypeActivityTaskCompleted {
-				// Store the name of the last completed activity.
-				//lastActivity = event.ActivityTaskCompletedEventAttributes.ActivityType.Name
-			}
+// checkStep checks that a submission can complete step, which the execution
+// is on as current. It writes the error response and returns false when it
+// cannot.
+func checkStep(w http.ResponseWriter, step workflows.StepDefinition, data Mystruct, current workflows.WorkflowStep) bool {
+	if step.Review != nil {
+		writeConflict(w, "Waiting on review of "+step.Action, current)
+		return false
+	}
+	if step.Upload != nil {
+		writeConflict(w, "Waiting on upload of "+step.Action, current)
+		return false
+	}
+	if len(step.Documents) > 0 {
+		writeConflict(w, "Waiting on documents of "+step.Action, current)
+		return false
+	}
+	if err := workflows.ValidatePayload(step.Payload, data.Payload); err != nil {
+		http.Error(w, "Invalid payload for "+step.Action+": "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	if step.Availability != nil {
+		if _, err := workflows.NormalizeAvailability(*step.Availability, data.Payload, time.Now()); err != nil {
+			http.Error(w, "Invalid availability: "+err.Error(), http.StatusBadRequest)
+			return false
 		}
-		h.logger.Info("******", zap.String("WorkflowId", workflowId))
+	}
+	return true
+}
 
-		js, _ := json.Marshal("Success")
+// submitWithStart starts the applicant's journey with a submission for its
+// first step. A journey started in the meantime gets the submission as a
+// signal. Journeys without a first step are not started.
+func (h *Service) submitWithStart(ctx context.Context, w http.ResponseWriter, root workflows.Execution, data Mystruct, wait bool) {
+	journey := data.Journey
+	if journey == "" {
+		journey = rootJourney
+	}
+	entry, ok := h.registry[journey]
+	if !ok || entry.Input != inputApplicant {
+		http.Error(w, "Unknown journey "+journey+"!", http.StatusNotFound)
+		return
+	}
+	// Journeys without a first step of their own, like onboarding, hand the
+	// applicant to child executions that are not started yet. They have to be
+	// started before the applicant submits.
+	step, ok := workflows.FirstStep(h.journeys, journey)
+	if !ok {
+		http.Error(w, "Journey "+journey+" must be started before submitting!", http.StatusConflict)
+		return
+	}
+	current := workflows.WorkflowStep{Action: step.Action, Index: 1, Status: workflows.StatusNotStarted}
+	if data.Action == "" {
+		http.Error(w, "Missing action!", http.StatusBadRequest)
+		return
+	}
+	if data.Action != step.Action {
+		writeConflict(w, "Submission for "+data.Action+" while waiting on "+step.Action, current)
+		return
+	}
+	if !checkStep(w, step, data, current) {
+		return
+	}
 
-		w.Header().Set("Content-Type", "application/json")
-		_, _ = w.Write(js)
-	} else {
-		_, _ = w.Write([]byte("Invalid Method!" + r.Method))
+	data.WorkflowId = root.WorkflowID
+	data.RunId = ""
+	execution, err := h.signalWithStart(journey, entry, data.ApplicantId, workflows.SignalName, data)
+	if err != nil {
+		h.logger.Error("Signal with start failed.", zap.String("WorkflowId", root.WorkflowID), zap.Error(err))
+		http.Error(w, "Error starting journey workflow!", http.StatusBadRequest)
+		return
 	}
+	h.writeSubmitted(ctx, w, workflows.Execution{WorkflowID: execution.WorkflowID}, execution, wait, 0)
 }
 
-func (h *Service) signalHelloWorld(w http.ResponseWriter, r *http.Request) {
-	if r.Method == "POST" {
-		workflowId := r.URL.Query().Get("workflowId")
-		age, err := strconv.Atoi(r.URL.Query().Get("age"))
-		if err != nil {
-			h.logger.Error("Failed to parse age from request!")
-		}
+// writeSubmitted writes the response to a submission delivered to signaled,
+// an execution in the status tree under root. When wait is set it polls the
+// tree, up to the submit wait timeout or until ctx is done, until the
+// sequence of signaled has moved past sequence, its value before the signal,
+// so the response shows the step the applicant is on next. Otherwise the
+// response shows the tree as the query finds it, which may be before the
+// signal is processed.
+func (h *Service) writeSubmitted(ctx context.Context, w http.ResponseWriter, root workflows.Execution, signaled workflows.Execution, wait bool, sequence int) {
+	applied := func(status workflows.JourneyStatus) bool {
+		node, ok := findExecutionStatus(status, signaled.WorkflowID)
+		return ok && node.Sequence > sequence
+	}
 
-		err = h.cadenceAdapter.CadenceClient.SignalWorkflow(context.Background(), workflowId, "", workflows.SignalName, age)
-		if err != nil {
-			http.Error(w, "Error signaling workflow!", http.StatusBadRequest)
-			return
+	timeout := h.submitWaitTimeout
+	if timeout <= 0 {
+		timeout = defaultSubmitWaitTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(submitPollInterval)
+	defer ticker.Stop()
+
+	result := submitResponse{Execution: signaled}
+	status, err := h.queryStatus(root)
+poll:
+	for wait && err == nil && !applied(status) {
+		select {
+		case <-ctx.Done():
+			// The wait timed out or the client went away.
+			break poll
+		case <-ticker.C:
+			status, err = h.queryStatus(root)
 		}
-
-		h.logger.Info("Signaled work flow with the following params!", zap.String("WorkflowId", workflowId), zap.Int("Age", age))
-
-		js, _ := json.Marshal("Success")
-
-		w.Header().Set("Content-Type", "application/json")
-		_, _ = w.Write(js)
+	}
+	if err != nil {
+		h.logger.Error("Query status after submit failed.", zap.String("WorkflowId", root.WorkflowID), zap.Error(err))
 	} else {
-		_, _ = w.Write([]byte("Invalid Method!" + r.Method))
+		next := activeStatus(status)
+		current := positionOf(next).Current
+		result = submitResponse{Execution: next.Execution, Current: &current}
+		if wait {
+			result.Status = &status
+			result.Pending = !applied(status)
+		}
 	}
-}
 
-
-type Mystruct struct {
-	WorkflowId string `json:"workflowId"`
-	RunId string `json:"runId"`
-	Payload interface{} `json:"payload"`
-	ApplicantId string `json:"applicantId"`
+	js, _ := json.Marshal(result)
+	w.Header().Set("Content-Type", "application/json")
+	if result.Pending {
+		w.WriteHeader(http.StatusAccepted)
+	}
+	_, _ = w.Write(js)
 }
 
-func (h *Service) submit(w http.ResponseWriter, r *http.Request) {
+func (h *Service) goBack(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "POST" {
-		h.logger.Info("$$$$$")
-
 		data := Mystruct{}
 		err := json.NewDecoder(r.Body).Decode(&data)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		if data.Action == "" {
+			http.Error(w, "Missing action!", http.StatusBadRequest)
+			return
+		}
 
-		workflowId := data.WorkflowId
-		h.logger.Info(workflowId)
-		h.logger.Info("payload", zap.Any("data", data))
-		//runId := payload.RunId
+		root, ok := rootExecution(data.WorkflowId, data.RunId, data.ApplicantId, data.Journey)
+		if !ok {
+			http.Error(w, "Missing workflowId or applicantId!", http.StatusBadRequest)
+			return
+		}
+		active, err := h.queryActive(root)
+		if err != nil {
+			http.Error(w, "Error getting status workflow!", http.StatusBadRequest)
+			return
+		}
+		position := positionOf(active)
+		if data.Action == position.Current.Action {
+			writeConflict(w, "Already on "+data.Action, position.Current)
+			return
+		}
 
-		err = h.cadenceAdapter.CadenceClient.SignalWorkflow(context.Background(), workflowId, "", workflows.SignalName, data)
+		data.WorkflowId = active.WorkflowID
+		data.RunId = active.RunID
+		err = h.cadenceAdapter.CadenceClient.SignalWorkflow(context.Background(), active.WorkflowID, active.RunID, workflows.GoBackSignalName, data)
 		if err != nil {
 			http.Error(w, "Error signaling workflow!", http.StatusBadRequest)
 			return
 		}
 
-		h.logger.Info("Signaled work flow with the following params!", zap.String("WorkflowId", workflowId), zap.Int("Age", 1))
+		h.logger.Info("Signaled go-back!", zap.String("WorkflowId", active.WorkflowID), zap.String("Action", data.Action))
 
 		js, _ := json.Marshal("Success")
 
@@ -433,7 +483,6 @@ func (h *Service) submit(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-
 func (h *Service) orientationStart(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "POST" {
 		accountId := r.URL.Query().Get("accountId")
@@ -629,19 +678,61 @@ func main() {
 	var cadenceClient cadenceAdapter.CadenceAdapter
 	cadenceClient.Setup(&appConfig.Cadence)
 
-	service := Service{&cadenceClient, appConfig.Logger}
-	http.HandleFunc("/api/start-teacher-onboarding", service.triggerTeacherJourney)
-	http.HandleFunc("/api/start-signup-workflow", service.triggerSignup)
-	http.HandleFunc("/api/start-orientation-workflow", service.triggerOrientation)
-	http.HandleFunc("/api/start-setup-workflow", service.triggerSetup)
-	http.HandleFunc("/api/start-onboarding-workflow", service.triggerOnboarding)
+	journeys, err := workflows.LoadJourneys(appConfig.JourneysPath)
+	if err != nil {
+		appConfig.Logger.Fatal("Failed to load journey definitions.", zap.Error(err))
+	}
+	agreements, err := workflows.LoadAgreements(appConfig.AgreementsPath)
+	if err != nil {
+		appConfig.Logger.Fatal("Failed to load agreements.", zap.Error(err))
+	}
+
+	reusePolicy, ok := reusePolicies[appConfig.WorkflowIDReusePolicy]
+	if !ok {
+		appConfig.Logger.Fatal("Unknown workflow ID reuse policy.", zap.String("WorkflowIDReusePolicy", appConfig.WorkflowIDReusePolicy))
+	}
+
+	objectStore := objectstore.NewLocal(appConfig.ObjectStore)
+	service := Service{
+		cadenceAdapter: &cadenceClient,
+		logger:         appConfig.Logger,
+		journeys:       journeys,
+		registry:       newRegistry(journeys, reusePolicy, appConfig.Logger),
+		objectStore:    objectStore,
+		agreements:     agreements,
+
+		reviewSearchAttributes: appConfig.ReviewSearchAttributes,
+		submitWaitTimeout:      appConfig.SubmitWaitTimeout,
+	}
+	http.HandleFunc("/api/workflows/", service.startWorkflow)
+	http.HandleFunc("/api/start-teacher-onboarding", service.startHandler("teacher-onboarding"))
+	http.HandleFunc("/api/start-signup-workflow", service.startHandler("signup"))
+	http.HandleFunc("/api/start-orientation-workflow", service.startHandler("orientation"))
+	http.HandleFunc("/api/start-setup-workflow", service.startHandler("setup"))
+	http.HandleFunc("/api/start-onboarding-workflow", service.startHandler("onboarding"))
+	http.HandleFunc("/api/journeys/", service.resumeOrStart)
 	http.HandleFunc("/api/get-current-screen", service.LastCompletedActivity)
 	http.HandleFunc("/api/submit", service.submit)
+	http.HandleFunc("/api/agreements/", service.getAgreement)
+	http.HandleFunc("/api/go-back", service.goBack)
+	http.HandleFunc("/api/reviews", service.listReviews)
+	http.HandleFunc("/api/reviews/claim", service.reviewHandler(workflows.ClaimSignalName))
+	http.HandleFunc("/api/reviews/approve", service.reviewHandler(workflows.ApproveSignalName))
+	http.HandleFunc("/api/reviews/reject", service.reviewHandler(workflows.RejectSignalName))
+	http.HandleFunc("/api/uploads", service.requestUpload)
+	http.HandleFunc("/api/uploads/complete", service.completeUpload)
+	http.HandleFunc("/api/documents", service.listDocuments)
+	http.HandleFunc("/api/documents/upload", service.requestDocumentUpload)
+	http.HandleFunc("/api/documents/complete", service.completeDocumentUpload)
+	http.HandleFunc("/api/documents/verify", service.documentDecisionHandler(workflows.VerifyDocumentSignalName))
+	http.HandleFunc("/api/documents/reject", service.documentDecisionHandler(workflows.RejectDocumentSignalName))
+	http.Handle(objectstore.UploadPath, objectStore.Handler())
 	http.HandleFunc("/api/signal-hello-world", service.signalHelloWorld)
 	http.HandleFunc("/api/orientation-start", service.orientationStart)
 	http.HandleFunc("/api/start-parent", service.parentStart)
 	http.HandleFunc("/api/history", service.check)
-	http.HandleFunc("/api/get-status-single", service.getStatusSingle)
+	http.HandleFunc("/api/status", service.getStatus)
+	http.HandleFunc("/api/get-status-single", service.getStatus)
 	http.HandleFunc("/api/get-status", service.getStatus)
 
 	addr := ":3030"
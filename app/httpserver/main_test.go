@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/adapters/cadenceAdapter"
+	"github.com/BhanuChandraAraveti/cadence-example/app/worker/workflows"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/mocks"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+)
+
+// testJourney is the journey the executions of the handler tests run.
+var testJourney = workflows.JourneyDefinition{
+	Name:    "test-journey",
+	Version: 1,
+	Steps: []workflows.StepDefinition{
+		{Action: "personal-info", Payload: []workflows.FieldSchema{{Name: "name", Type: "string", Required: true}}},
+		{Action: "contact"},
+	},
+}
+
+// jsonValue is a query result holding JSON.
+type jsonValue []byte
+
+func (v jsonValue) HasValue() bool { return v != nil }
+
+func (v jsonValue) Get(valuePtr interface{}) error { return json.Unmarshal(v, valuePtr) }
+
+func newTestService(t *testing.T) (*Service, *mocks.Client) {
+	cadenceClient := &mocks.Client{}
+	t.Cleanup(func() { cadenceClient.AssertExpectations(t) })
+	service := &Service{
+		cadenceAdapter: &cadenceAdapter.CadenceAdapter{CadenceClient: cadenceClient},
+		logger:         zap.NewNop(),
+		journeys:       []workflows.JourneyDefinition{testJourney},
+	}
+	service.registry = newRegistry(service.journeys, client.WorkflowIDReusePolicyAllowDuplicateFailedOnly, service.logger)
+	return service, cadenceClient
+}
+
+// onState answers the "state" query of workflowID with each of states in
+// turn, the last one from then on.
+func onState(cadenceClient *mocks.Client, workflowID string, states ...workflows.WorkflowState) {
+	isExecution := mock.MatchedBy(func(request *client.QueryWorkflowWithOptionsRequest) bool {
+		return request.WorkflowID == workflowID && request.QueryType == "state"
+	})
+	for i, state := range states {
+		js, _ := json.Marshal(state)
+		call := cadenceClient.On("QueryWorkflowWithOptions", mock.Anything, isExecution).
+			Return(&client.QueryWorkflowWithOptionsResponse{QueryResult: jsonValue(js)}, nil)
+		if i < len(states)-1 {
+			call.Once()
+		}
+	}
+}
+
+// testState is the state of an execution of testJourney waiting on action.
+func testState(action string, sequence int) workflows.WorkflowState {
+	state := workflows.WorkflowState{Journey: testJourney.Name, Version: testJourney.Version, Sequence: sequence}
+	for i, step := range testJourney.Steps {
+		status := workflows.StatusNotStarted
+		if step.Action == action {
+			status = workflows.StatusInProgress
+		} else if len(state.Steps) == 0 || state.Steps[len(state.Steps)-1].Status == workflows.StatusCompleted {
+			status = workflows.StatusCompleted
+		}
+		state.Steps = append(state.Steps, workflows.WorkflowStep{Action: step.Action, Index: i + 1, Status: status})
+		if step.Action == action {
+			state.Current = state.Steps[i]
+		}
+	}
+	return state
+}
+
+func post(handler http.HandlerFunc, target string, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest("POST", target, strings.NewReader(body)))
+	return recorder
+}
+
+func TestSubmitInvalidPayload(t *testing.T) {
+	service, cadenceClient := newTestService(t)
+	onState(cadenceClient, "wf-1", testState("personal-info", 0))
+
+	recorder := post(service.submit, "/api/submit", `{"workflowId": "wf-1", "action": "personal-info", "payload": {}}`)
+
+	require.Equal(t, http.StatusBadRequest, recorder.Code, recorder.Body.String())
+	require.Contains(t, recorder.Body.String(), `field "name" is required`)
+	cadenceClient.AssertNotCalled(t, "SignalWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSubmitForAnotherStepConflicts(t *testing.T) {
+	service, cadenceClient := newTestService(t)
+	onState(cadenceClient, "wf-1", testState("personal-info", 0))
+
+	recorder := post(service.submit, "/api/submit", `{"workflowId": "wf-1", "action": "contact"}`)
+
+	require.Equal(t, http.StatusConflict, recorder.Code, recorder.Body.String())
+	var response conflictResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.Equal(t, "personal-info", response.Current.Action)
+	cadenceClient.AssertNotCalled(t, "SignalWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSubmitSignalsCurrentStep(t *testing.T) {
+	service, cadenceClient := newTestService(t)
+	onState(cadenceClient, "wf-1", testState("personal-info", 0))
+	cadenceClient.On("SignalWorkflow", mock.Anything, "wf-1", "", workflows.SignalName, mock.MatchedBy(func(data Mystruct) bool {
+		return data.WorkflowId == "wf-1" && data.Action == "personal-info"
+	})).Return(nil).Once()
+
+	recorder := post(service.submit, "/api/submit", `{"workflowId": "wf-1", "action": "personal-info", "payload": {"name": "Asha"}}`)
+
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var response submitResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.Equal(t, "wf-1", response.WorkflowID)
+	require.NotNil(t, response.Current)
+	require.Equal(t, "personal-info", response.Current.Action)
+}
+
+func TestSubmitByApplicantSignalsActiveChild(t *testing.T) {
+	service, cadenceClient := newTestService(t)
+	rootID := workflows.WorkflowID("onboarding", "applicant-1")
+	childID := workflows.WorkflowID("orientation", "applicant-1")
+	cadenceClient.On("DescribeWorkflowExecution", mock.Anything, rootID, "").Return(&s.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &s.WorkflowExecutionInfo{Execution: &s.WorkflowExecution{WorkflowId: &rootID}},
+	}, nil).Once()
+	root := workflows.WorkflowState{Steps: []workflows.WorkflowStep{
+		{Action: "orientation", Index: 1, Status: workflows.StatusInProgress, WorkflowID: &childID},
+	}}
+	root.Current = root.Steps[0]
+	onState(cadenceClient, rootID, root)
+	onState(cadenceClient, childID, testState("personal-info", 0))
+	cadenceClient.On("SignalWorkflow", mock.Anything, childID, "", workflows.SignalName, mock.MatchedBy(func(data Mystruct) bool {
+		return data.WorkflowId == childID && data.ApplicantId == "applicant-1"
+	})).Return(nil).Once()
+
+	recorder := post(service.submit, "/api/submit", `{"applicantId": "applicant-1", "action": "personal-info", "payload": {"name": "Asha"}}`)
+
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var response submitResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.Equal(t, childID, response.WorkflowID)
+}
+
+func TestSubmitStartsJourneyWithFirstSubmission(t *testing.T) {
+	service, cadenceClient := newTestService(t)
+	workflowID := workflows.WorkflowID(testJourney.Name, "applicant-1")
+	cadenceClient.On("DescribeWorkflowExecution", mock.Anything, workflowID, "").Return(nil, &s.EntityNotExistsError{}).Once()
+	isFirstStep := mock.MatchedBy(func(data Mystruct) bool { return data.Action == "personal-info" })
+	isJourney := mock.MatchedBy(func(options client.StartWorkflowOptions) bool { return options.ID == workflowID })
+	cadenceClient.On("SignalWithStartWorkflow", mock.Anything, workflowID, workflows.SignalName, isFirstStep, isJourney, testJourney.Name, "applicant-1").
+		Return(&workflow.Execution{ID: workflowID, RunID: "run-1"}, nil).Once()
+	onState(cadenceClient, workflowID, testState("contact", 1))
+
+	recorder := post(service.submit, "/api/submit", `{"applicantId": "applicant-1", "journey": "test-journey", "action": "personal-info", "payload": {"name": "Asha"}}`)
+
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var response submitResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.Equal(t, workflowID, response.WorkflowID)
+	require.Equal(t, "contact", response.Current.Action)
+}
+
+func TestSubmitStartingJourneyChecksFirstStep(t *testing.T) {
+	service, cadenceClient := newTestService(t)
+	workflowID := workflows.WorkflowID(testJourney.Name, "applicant-1")
+	cadenceClient.On("DescribeWorkflowExecution", mock.Anything, workflowID, "").Return(nil, &s.EntityNotExistsError{}).Twice()
+
+	recorder := post(service.submit, "/api/submit", `{"applicantId": "applicant-1", "journey": "test-journey", "action": "contact"}`)
+	require.Equal(t, http.StatusConflict, recorder.Code, recorder.Body.String())
+
+	recorder = post(service.submit, "/api/submit", `{"applicantId": "applicant-1", "journey": "test-journey", "action": "personal-info", "payload": {}}`)
+	require.Equal(t, http.StatusBadRequest, recorder.Code, recorder.Body.String())
+}
+
+func TestSubmitStartingJourneyWithoutFirstStepConflicts(t *testing.T) {
+	service, cadenceClient := newTestService(t)
+	cadenceClient.On("DescribeWorkflowExecution", mock.Anything, workflows.WorkflowID("onboarding", "applicant-1"), "").
+		Return(nil, &s.EntityNotExistsError{}).Once()
+
+	recorder := post(service.submit, "/api/submit", `{"applicantId": "applicant-1", "action": "orientation"}`)
+
+	require.Equal(t, http.StatusConflict, recorder.Code, recorder.Body.String())
+	cadenceClient.AssertNotCalled(t, "SignalWithStartWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSubmitWaitsForSubmissionToBeApplied(t *testing.T) {
+	service, cadenceClient := newTestService(t)
+	onState(cadenceClient, "wf-1", testState("personal-info", 2), testState("personal-info", 2), testState("contact", 3))
+	cadenceClient.On("SignalWorkflow", mock.Anything, "wf-1", "", workflows.SignalName, mock.Anything).Return(nil).Once()
+
+	recorder := post(service.submit, "/api/submit?wait=true", `{"workflowId": "wf-1", "action": "personal-info", "payload": {"name": "Asha"}}`)
+
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var response submitResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.False(t, response.Pending)
+	require.NotNil(t, response.Status)
+	require.Equal(t, 3, response.Status.Sequence)
+	require.Equal(t, "contact", response.Current.Action)
+}
+
+func TestSubmitWaitIsBounded(t *testing.T) {
+	service, cadenceClient := newTestService(t)
+	service.submitWaitTimeout = 500 * time.Millisecond
+	onState(cadenceClient, "wf-1", testState("personal-info", 2))
+	cadenceClient.On("SignalWorkflow", mock.Anything, "wf-1", "", workflows.SignalName, mock.Anything).Return(nil).Once()
+
+	recorder := post(service.submit, "/api/submit?wait=true", `{"workflowId": "wf-1", "action": "personal-info", "payload": {"name": "Asha"}}`)
+
+	require.Equal(t, http.StatusAccepted, recorder.Code, recorder.Body.String())
+	var response submitResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.True(t, response.Pending)
+	require.Equal(t, "personal-info", response.Current.Action)
+}
+
+func TestSubmitWaitStopsWhenClientGoesAway(t *testing.T) {
+	service, cadenceClient := newTestService(t)
+	service.submitWaitTimeout = time.Hour
+	onState(cadenceClient, "wf-1", testState("personal-info", 2))
+	cadenceClient.On("SignalWorkflow", mock.Anything, "wf-1", "", workflows.SignalName, mock.Anything).Return(nil).Once()
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("POST", "/api/submit?wait=true", strings.NewReader(`{"workflowId": "wf-1", "action": "personal-info", "payload": {"name": "Asha"}}`))
+	service.submit(recorder, request.WithContext(ctx))
+
+	require.Less(t, int64(time.Since(started)), int64(10*time.Second))
+	require.Equal(t, http.StatusAccepted, recorder.Code, recorder.Body.String())
+}
+
+// legacyState is the state of an execution started before journeys ran on the
+// step runner, waiting on action.
+func legacyState(action string) workflows.WorkflowState {
+	current := workflows.WorkflowStep{Action: action, Index: 1, Status: workflows.StatusInProgress}
+	return workflows.WorkflowState{Current: current, Steps: []workflows.WorkflowStep{current}}
+}
+
+func TestSubmitToLegacyExecution(t *testing.T) {
+	tests := []struct {
+		name   string
+		state  workflows.WorkflowState
+		body   string
+		status int
+	}{
+		{"without action", legacyState("degree-details"), `{"workflowId": "wf-1", "payload": {}}`, http.StatusOK},
+		{"for the current step", legacyState("degree-details"), `{"workflowId": "wf-1", "action": "degree-details", "payload": {}}`, http.StatusOK},
+		{"for another step", legacyState("degree-details"), `{"workflowId": "wf-1", "action": "stream-selection"}`, http.StatusConflict},
+		{"without a current step", workflows.WorkflowState{}, `{"workflowId": "wf-1"}`, http.StatusConflict},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service, cadenceClient := newTestService(t)
+			onState(cadenceClient, "wf-1", test.state)
+			if test.status == http.StatusOK {
+				cadenceClient.On("SignalWorkflow", mock.Anything, "wf-1", "", workflows.SignalName, mock.Anything).Return(nil).Once()
+			}
+
+			recorder := post(service.submit, "/api/submit", test.body)
+
+			require.Equal(t, test.status, recorder.Code, recorder.Body.String())
+			if test.status != http.StatusOK {
+				cadenceClient.AssertNotCalled(t, "SignalWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+// app/httpserver/registry.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/worker/workflows"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/client"
+	"go.uber.org/zap"
+)
+
+// Inputs a registered workflow can take.
+const (
+	// inputNone workflows take no arguments.
+	inputNone = iota
+	// inputApplicant workflows take the applicant ID as their only argument.
+	inputApplicant
+)
+
+// workflowEntry describes a workflow that can be started over HTTP.
+type workflowEntry struct {
+	// Workflow is the workflow function or the name it is registered under.
+	Workflow interface{}
+	Input    int
+	Options  client.StartWorkflowOptions
+	// Query runs the "state" query right after the workflow is started.
+	Query bool
+}
+
+// reusePolicies maps the WorkflowIDReusePolicy names accepted in the config to
+// the client policies.
+var reusePolicies = map[string]client.WorkflowIDReusePolicy{
+	"":                         client.WorkflowIDReusePolicyAllowDuplicateFailedOnly,
+	"AllowDuplicateFailedOnly": client.WorkflowIDReusePolicyAllowDuplicateFailedOnly,
+	"AllowDuplicate":           client.WorkflowIDReusePolicyAllowDuplicate,
+	"RejectDuplicate":          client.WorkflowIDReusePolicyRejectDuplicate,
+	"TerminateIfRunning":       client.WorkflowIDReusePolicyTerminateIfRunning,
+}
+
+// builtinJourneys maps the built-in entries that run a journey to its name.
+var builtinJourneys = map[string]string{
+	"setup":              "setup",
+	"teacher-onboarding": "teacher-journey",
+}
+
+// newRegistry returns the workflows that can be started over HTTP, keyed by
+// name. Journeys loaded from definitions are started by their registered name
+// with the timeout of their latest version, and so are the built-in entries
+// running a journey. A built-in entry keeps its name when a definition has the
+// same one, so /api/start-* go on starting the same workflow type; the
+// collision is logged.
+func newRegistry(defs []workflows.JourneyDefinition, reusePolicy client.WorkflowIDReusePolicy, logger *zap.Logger) map[string]workflowEntry {
+	options := func(timeout time.Duration) client.StartWorkflowOptions {
+		return client.StartWorkflowOptions{
+			TaskList:                     workflows.TaskListName,
+			ExecutionStartToCloseTimeout: timeout,
+			WorkflowIDReusePolicy:        reusePolicy,
+		}
+	}
+
+	registry := map[string]workflowEntry{
+		"signup":             {workflows.SignupWorkflow, inputNone, options(time.Hour * 24), true},
+		"teacher-onboarding": {workflows.TeacherJourneyWorkflow, inputNone, options(time.Hour * 24), true},
+		"orientation":        {workflows.OrientationWorkflow, inputApplicant, options(time.Hour * 24), true},
+		"setup":              {workflows.SetupWorkflow, inputApplicant, options(time.Hour * 24), true},
+		"onboarding":         {workflows.OnboardingWorkflow, inputApplicant, options(time.Hour * 24), true},
+	}
+
+	builtin := map[string]bool{}
+	collided := map[string]bool{}
+	for name := range registry {
+		builtin[name] = true
+	}
+
+	// defs are sorted by name and version, so the last one wins.
+	for _, def := range defs {
+		for name, journey := range builtinJourneys {
+			if journey == def.Name && def.Timeout > 0 {
+				entry := registry[name]
+				entry.Options = options(def.Timeout)
+				registry[name] = entry
+			}
+		}
+		if builtin[def.Name] {
+			if !collided[def.Name] {
+				logger.Warn("Journey definition has the name of a built-in workflow; the built-in is started.", zap.String("Name", def.Name))
+				collided[def.Name] = true
+			}
+			continue
+		}
+		timeout := def.Timeout
+		if timeout <= 0 {
+			timeout = time.Hour * 24
+		}
+		registry[def.Name] = workflowEntry{def.Name, inputApplicant, options(timeout), true}
+	}
+	return registry
+}
+
+// startRequest is the body of POST /api/workflows/{name}. The applicant ID may
+// also be passed as the applicant_id query parameter.
+type startRequest struct {
+	ApplicantID string `json:"applicant_id"`
+}
+
+// journeyResponse is returned when a workflow is started or resumed, with the
+// execution and the result of its "state" query.
+type journeyResponse struct {
+	workflows.Execution
+	State json.RawMessage `json:"workflow_state,omitempty"`
+}
+
+// startOptions returns the options and arguments the workflow of a registry
+// entry is started with. Workflows taking the applicant ID get a workflow ID
+// derived from it and the entry name, so an applicant has at most one running
+// execution of each; other workflows get a generated ID.
+func startOptions(name string, entry workflowEntry, applicantID string) (client.StartWorkflowOptions, []interface{}) {
+	wo := entry.Options
+	var args []interface{}
+	if entry.Input == inputApplicant {
+		wo.ID = workflows.WorkflowID(name, applicantID)
+		args = append(args, applicantID)
+	}
+	return wo, args
+}
+
+// start starts the workflow of a registry entry.
+func (h *Service) start(name string, entry workflowEntry, applicantID string) (workflows.Execution, error) {
+	wo, args := startOptions(name, entry, applicantID)
+	execution, err := h.cadenceAdapter.CadenceClient.StartWorkflow(context.Background(), wo, entry.Workflow, args...)
+	if err != nil {
+		return workflows.Execution{}, err
+	}
+	h.logger.Info("Started work flow!", zap.String("Name", name), zap.String("WorkflowId", execution.ID), zap.String("RunId", execution.RunID))
+	return workflows.Execution{WorkflowID: execution.ID, RunID: execution.RunID}, nil
+}
+
+// signalWithStart sends a signal to the applicant's running workflow of a
+// registry entry. When it is not running the workflow is started and signaled
+// at once, so the signal cannot be lost between the two.
+func (h *Service) signalWithStart(name string, entry workflowEntry, applicantID string, signalName string, arg interface{}) (workflows.Execution, error) {
+	wo, args := startOptions(name, entry, applicantID)
+	execution, err := h.cadenceAdapter.CadenceClient.SignalWithStartWorkflow(context.Background(), wo.ID, signalName, arg, wo, entry.Workflow, args...)
+	if err != nil {
+		return workflows.Execution{}, err
+	}
+	h.logger.Info("Signaled with start work flow!", zap.String("Name", name), zap.String("WorkflowId", execution.ID), zap.String("RunId", execution.RunID))
+	return workflows.Execution{WorkflowID: execution.ID, RunID: execution.RunID}, nil
+}
+
+// queryState returns the raw result of the "state" query of an execution.
+func (h *Service) queryState(execution workflows.Execution) (json.RawMessage, error) {
+	resp, err := h.cadenceAdapter.CadenceClient.QueryWorkflowWithOptions(context.Background(), &client.QueryWorkflowWithOptionsRequest{
+		WorkflowID:            execution.WorkflowID,
+		RunID:                 execution.RunID,
+		QueryType:             "state",
+		QueryConsistencyLevel: s.QueryConsistencyLevelStrong.Ptr(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var state json.RawMessage
+	err = resp.QueryResult.Get(&state)
+	return state, err
+}
+
+// findExecution returns the latest execution with the given workflow ID and
+// whether it is still running.
+func (h *Service) findExecution(workflowID string) (workflows.Execution, bool, error) {
+	resp, err := h.cadenceAdapter.CadenceClient.DescribeWorkflowExecution(context.Background(), workflowID, "")
+	if err != nil {
+		return workflows.Execution{}, false, err
+	}
+	info := resp.WorkflowExecutionInfo
+	execution := workflows.Execution{
+		WorkflowID: info.Execution.GetWorkflowId(),
+		RunID:      info.Execution.GetRunId(),
+	}
+	return execution, info.CloseStatus == nil, nil
+}
+
+func writeJourney(w http.ResponseWriter, result journeyResponse) {
+	js, _ := json.Marshal(result)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(js)
+}
+
+// startWorkflow handles POST /api/workflows/{name}.
+func (h *Service) startWorkflow(w http.ResponseWriter, r *http.Request) {
+	h.startHandler(strings.TrimPrefix(r.URL.Path, "/api/workflows/"))(w, r)
+}
+
+// startHandler returns the handler starting the registered workflow name.
+func (h *Service) startHandler(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			entry, ok := h.registry[name]
+			if !ok {
+				http.Error(w, "Unknown workflow "+name+"!", http.StatusNotFound)
+				return
+			}
+
+			data := startRequest{}
+			if err := json.NewDecoder(r.Body).Decode(&data); err != nil && err != io.EOF {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if data.ApplicantID == "" {
+				data.ApplicantID = r.URL.Query().Get("applicant_id")
+			}
+			if entry.Input == inputApplicant && data.ApplicantID == "" {
+				http.Error(w, "Missing applicant_id!", http.StatusBadRequest)
+				return
+			}
+
+			execution, err := h.start(name, entry, data.ApplicantID)
+			if err != nil {
+				h.logger.Error("Start workflow failed.", zap.String("Name", name), zap.Error(err))
+				http.Error(w, "Error starting "+name+" workflow!", http.StatusBadRequest)
+				return
+			}
+
+			result := journeyResponse{Execution: execution}
+			if entry.Query {
+				result.State, err = h.queryState(execution)
+				if err != nil {
+					http.Error(w, "Error starting "+name+" workflow!", http.StatusBadRequest)
+					return
+				}
+			}
+			writeJourney(w, result)
+		} else {
+			_, _ = w.Write([]byte("Invalid Method!" + r.Method))
+		}
+	}
+}
+
+// resumeOrStart handles /api/journeys/{type}/{applicant_id}. It returns the
+// state of the applicant's running journey of that type, or starts one if none
+// is running. When the reuse policy does not allow a new run, the state of the
+// last run is returned.
+func (h *Service) resumeOrStart(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/journeys/"), "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			http.Error(w, "Expected /api/journeys/{type}/{applicant_id}!", http.StatusBadRequest)
+			return
+		}
+		journeyType, applicantID := parts[0], parts[1]
+		entry, ok := h.registry[journeyType]
+		if !ok || entry.Input != inputApplicant {
+			http.Error(w, "Unknown journey "+journeyType+"!", http.StatusNotFound)
+			return
+		}
+
+		workflowID := workflows.WorkflowID(journeyType, applicantID)
+		execution, running, err := h.findExecution(workflowID)
+		if _, notFound := err.(*s.EntityNotExistsError); err != nil && !notFound {
+			h.logger.Error("Describe workflow failed.", zap.String("WorkflowId", workflowID), zap.Error(err))
+			http.Error(w, "Error getting journey workflow!", http.StatusBadRequest)
+			return
+		}
+		if !running {
+			execution, err = h.start(journeyType, entry, applicantID)
+			if _, alreadyStarted := err.(*s.WorkflowExecutionAlreadyStartedError); alreadyStarted {
+				execution, _, err = h.findExecution(workflowID)
+			}
+			if err != nil {
+				h.logger.Error("Start journey workflow failed.", zap.String("WorkflowId", workflowID), zap.Error(err))
+				http.Error(w, "Error starting journey workflow!", http.StatusBadRequest)
+				return
+			}
+		}
+
+		state, err := h.queryState(execution)
+		if err != nil {
+			http.Error(w, "Error getting status workflow!", http.StatusBadRequest)
+			return
+		}
+		writeJourney(w, journeyResponse{Execution: execution, State: state})
+	} else {
+		_, _ = w.Write([]byte("Invalid Method!" + r.Method))
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/worker/workflows"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/mocks"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+)
+
+func testRegistry(defs ...workflows.JourneyDefinition) map[string]workflowEntry {
+	return newRegistry(defs, client.WorkflowIDReusePolicyAllowDuplicateFailedOnly, zap.NewNop())
+}
+
+func TestRegistryStartsJourneysByName(t *testing.T) {
+	registry := testRegistry(
+		workflows.JourneyDefinition{Name: "lead", Version: 1, Timeout: time.Hour},
+		workflows.JourneyDefinition{Name: "lead", Version: 2, Timeout: 2 * time.Hour},
+		workflows.JourneyDefinition{Name: "application", Version: 1},
+	)
+
+	require.Equal(t, "lead", registry["lead"].Workflow)
+	require.Equal(t, inputApplicant, registry["lead"].Input)
+	require.Equal(t, 2*time.Hour, registry["lead"].Options.ExecutionStartToCloseTimeout)
+	require.Equal(t, 24*time.Hour, registry["application"].Options.ExecutionStartToCloseTimeout)
+	require.Equal(t, workflows.TaskListName, registry["application"].Options.TaskList)
+}
+
+func TestRegistryKeepsBuiltinOnNameCollision(t *testing.T) {
+	registry := testRegistry(workflows.JourneyDefinition{Name: "orientation", Version: 1, Timeout: time.Hour})
+
+	_, byName := registry["orientation"].Workflow.(string)
+	require.False(t, byName, "the built-in workflow function is started")
+	require.Equal(t, 24*time.Hour, registry["orientation"].Options.ExecutionStartToCloseTimeout)
+}
+
+func TestRegistryBuiltinsRunWithTheirJourneyTimeout(t *testing.T) {
+	registry := testRegistry(
+		workflows.JourneyDefinition{Name: "setup", Version: 4},
+		workflows.JourneyDefinition{Name: "setup", Version: 5, Timeout: 168 * time.Hour},
+		workflows.JourneyDefinition{Name: "teacher-journey", Version: 2, Timeout: 48 * time.Hour},
+	)
+
+	_, byName := registry["setup"].Workflow.(string)
+	require.False(t, byName, "the built-in workflow function is started")
+	require.Equal(t, 168*time.Hour, registry["setup"].Options.ExecutionStartToCloseTimeout)
+	require.Equal(t, 48*time.Hour, registry["teacher-onboarding"].Options.ExecutionStartToCloseTimeout)
+	require.Equal(t, 48*time.Hour, registry["teacher-journey"].Options.ExecutionStartToCloseTimeout)
+	require.Equal(t, 24*time.Hour, registry["signup"].Options.ExecutionStartToCloseTimeout)
+}
+
+func TestStartOptionsDeriveIDFromApplicant(t *testing.T) {
+	registry := testRegistry()
+
+	options, args := startOptions("setup", registry["setup"], "applicant-1")
+	require.Equal(t, workflows.WorkflowID("setup", "applicant-1"), options.ID)
+	require.Equal(t, []interface{}{"applicant-1"}, args)
+
+	options, args = startOptions("signup", registry["signup"], "applicant-1")
+	require.Empty(t, options.ID)
+	require.Empty(t, args)
+}
+
+var journeyID = workflows.WorkflowID(testJourney.Name, "applicant-1")
+
+// onDescribe answers describing the journey execution with info, or fails
+// with err.
+func onDescribe(cadenceClient *mocks.Client, info *s.WorkflowExecutionInfo, err error) *mock.Call {
+	if err != nil {
+		return cadenceClient.On("DescribeWorkflowExecution", mock.Anything, journeyID, "").Return(nil, err)
+	}
+	return cadenceClient.On("DescribeWorkflowExecution", mock.Anything, journeyID, "").
+		Return(&s.DescribeWorkflowExecutionResponse{WorkflowExecutionInfo: info}, nil)
+}
+
+// onStart answers starting the journey execution with runID, or fails with
+// err.
+func onStart(cadenceClient *mocks.Client, runID string, err error) *mock.Call {
+	isJourney := mock.MatchedBy(func(options client.StartWorkflowOptions) bool { return options.ID == journeyID })
+	call := cadenceClient.On("StartWorkflow", mock.Anything, isJourney, testJourney.Name, "applicant-1")
+	if err != nil {
+		return call.Return(nil, err)
+	}
+	return call.Return(&workflow.Execution{ID: journeyID, RunID: runID}, nil)
+}
+
+func resumeOrStart(service *Service, target string) (*httptest.ResponseRecorder, journeyResponse) {
+	recorder := post(service.resumeOrStart, target, "")
+	var response journeyResponse
+	_ = json.Unmarshal(recorder.Body.Bytes(), &response)
+	return recorder, response
+}
+
+func TestResumeOrStartResumesRunningJourney(t *testing.T) {
+	service, cadenceClient := newTestService(t)
+	onDescribe(cadenceClient, executionInfo(journeyID), nil)
+	onState(cadenceClient, journeyID, testState("contact", 1))
+
+	recorder, response := resumeOrStart(service, "/api/journeys/test-journey/applicant-1")
+
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.Equal(t, journeyID+"-run", response.RunID)
+	var state workflows.WorkflowState
+	require.NoError(t, json.Unmarshal(response.State, &state))
+	require.Equal(t, "contact", state.Current.Action)
+	cadenceClient.AssertNotCalled(t, "StartWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestResumeOrStartStartsJourney(t *testing.T) {
+	closed := executionInfo(journeyID)
+	closed.CloseStatus = s.WorkflowExecutionCloseStatusFailed.Ptr()
+	tests := []struct {
+		name     string
+		info     *s.WorkflowExecutionInfo
+		describe error
+	}{
+		{"never started", nil, &s.EntityNotExistsError{}},
+		{"previous run closed", closed, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service, cadenceClient := newTestService(t)
+			onDescribe(cadenceClient, test.info, test.describe).Once()
+			onStart(cadenceClient, "run-2", nil).Once()
+			onState(cadenceClient, journeyID, testState("personal-info", 0))
+
+			recorder, response := resumeOrStart(service, "/api/journeys/test-journey/applicant-1")
+
+			require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+			require.Equal(t, workflows.Execution{WorkflowID: journeyID, RunID: "run-2"}, response.Execution)
+		})
+	}
+}
+
+func TestResumeOrStartResumesJourneyStartedConcurrently(t *testing.T) {
+	service, cadenceClient := newTestService(t)
+	onDescribe(cadenceClient, nil, &s.EntityNotExistsError{}).Once()
+	onStart(cadenceClient, "", &s.WorkflowExecutionAlreadyStartedError{}).Once()
+	onDescribe(cadenceClient, executionInfo(journeyID), nil).Once()
+	onState(cadenceClient, journeyID, testState("personal-info", 0))
+
+	recorder, response := resumeOrStart(service, "/api/journeys/test-journey/applicant-1")
+
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.Equal(t, journeyID+"-run", response.RunID)
+}
+
+func TestResumeOrStartFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		setup  func(cadenceClient *mocks.Client)
+		status int
+	}{
+		{"no applicant", "/api/journeys/test-journey", nil, http.StatusBadRequest},
+		{"unknown journey", "/api/journeys/teleport/applicant-1", nil, http.StatusNotFound},
+		{"journey without applicant", "/api/journeys/signup/applicant-1", nil, http.StatusNotFound},
+		{"describe fails", "/api/journeys/test-journey/applicant-1", func(cadenceClient *mocks.Client) {
+			onDescribe(cadenceClient, nil, &s.InternalServiceError{Message: "unavailable"})
+		}, http.StatusBadRequest},
+		{"start fails", "/api/journeys/test-journey/applicant-1", func(cadenceClient *mocks.Client) {
+			onDescribe(cadenceClient, nil, &s.EntityNotExistsError{})
+			onStart(cadenceClient, "", &s.BadRequestError{Message: "invalid"})
+		}, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service, cadenceClient := newTestService(t)
+			if test.setup != nil {
+				test.setup(cadenceClient)
+			}
+
+			recorder, _ := resumeOrStart(service, test.target)
+
+			require.Equal(t, test.status, recorder.Code, recorder.Body.String())
+		})
+	}
+}
+
+func TestResumeOrStartRejectsOtherMethods(t *testing.T) {
+	service, _ := newTestService(t)
+
+	recorder := httptest.NewRecorder()
+	service.resumeOrStart(recorder, httptest.NewRequest("GET", "/api/journeys/test-journey/applicant-1", nil))
+
+	require.Equal(t, "Invalid Method!GET", recorder.Body.String())
+}
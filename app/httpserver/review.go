@@ -0,0 +1,218 @@
+// app/httpserver/review.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/worker/workflows"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/client"
+	"go.uber.org/zap"
+)
+
+// pendingReview is an open review task of a running journey.
+type pendingReview struct {
+	workflows.Execution
+	Review workflows.ReviewTask `json:"review"`
+}
+
+// queryReview returns the open review task of an execution, or nil when it is
+// not waiting on a review.
+func (h *Service) queryReview(workflowID string, runID string) (*workflows.ReviewTask, error) {
+	resp, err := h.cadenceAdapter.CadenceClient.QueryWorkflowWithOptions(context.Background(), &client.QueryWorkflowWithOptionsRequest{
+		WorkflowID:            workflowID,
+		RunID:                 runID,
+		QueryType:             "review",
+		QueryConsistencyLevel: s.QueryConsistencyLevelStrong.Ptr(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var review *workflows.ReviewTask
+	err = resp.QueryResult.Get(&review)
+	return review, err
+}
+
+// reviewQuery returns the visibility query for running executions with an
+// open review task, assigned to or claimed by assignee when it is set.
+func reviewQuery(assignee string) string {
+	query := fmt.Sprintf("CloseTime = missing and (%s = '%s' or %s = '%s')",
+		workflows.ReviewStatusAttribute, workflows.ReviewPending, workflows.ReviewStatusAttribute, workflows.ReviewClaimed)
+	if assignee != "" {
+		query += fmt.Sprintf(" and (%s = '%s' or %s = '%s')",
+			workflows.ReviewAssigneeAttribute, assignee, workflows.ReviewerAttribute, assignee)
+	}
+	return query
+}
+
+// listReviews handles GET /api/reviews. It lists the open review tasks of all
+// running executions, optionally only those assigned to or claimed by the
+// assignee query parameter.
+func (h *Service) listReviews(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" {
+		assignee := r.URL.Query().Get("assignee")
+		if strings.ContainsAny(assignee, "'\\") {
+			http.Error(w, "Invalid assignee!", http.StatusBadRequest)
+			return
+		}
+
+		var reviews []pendingReview
+		var err error
+		if h.reviewSearchAttributes {
+			reviews, err = h.searchReviews(assignee)
+		} else {
+			reviews, err = h.scanReviews(assignee)
+		}
+		if err != nil {
+			h.logger.Error("List reviews failed.", zap.Error(err))
+			http.Error(w, "Error listing reviews!", http.StatusBadRequest)
+			return
+		}
+
+		js, _ := json.Marshal(reviews)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(js)
+	} else {
+		_, _ = w.Write([]byte("Invalid Method!" + r.Method))
+	}
+}
+
+// searchReviews finds the executions with an open review through the review
+// search attributes, which needs advanced visibility on the Cadence cluster.
+func (h *Service) searchReviews(assignee string) ([]pendingReview, error) {
+	reviews := []pendingReview{}
+	query := reviewQuery(assignee)
+	request := &s.ListWorkflowExecutionsRequest{Query: &query}
+	for {
+		resp, err := h.cadenceAdapter.CadenceClient.ListWorkflow(context.Background(), request)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range resp.Executions {
+			reviews, err = h.appendReview(reviews, info.Execution, "")
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(resp.NextPageToken) == 0 {
+			return reviews, nil
+		}
+		request.NextPageToken = resp.NextPageToken
+	}
+}
+
+// scanReviews queries every running execution for an open review. It works
+// with basic visibility, at the cost of a query per running execution.
+func (h *Service) scanReviews(assignee string) ([]pendingReview, error) {
+	reviews := []pendingReview{}
+	earliest, latest := int64(0), time.Now().UnixNano()
+	request := &s.ListOpenWorkflowExecutionsRequest{
+		StartTimeFilter: &s.StartTimeFilter{EarliestTime: &earliest, LatestTime: &latest},
+	}
+	for {
+		resp, err := h.cadenceAdapter.CadenceClient.ListOpenWorkflow(context.Background(), request)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range resp.Executions {
+			reviews, err = h.appendReview(reviews, info.Execution, assignee)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(resp.NextPageToken) == 0 {
+			return reviews, nil
+		}
+		request.NextPageToken = resp.NextPageToken
+	}
+}
+
+// appendReview appends the open review of an execution to reviews, if it has
+// one assigned to or claimed by assignee, or any when assignee is empty.
+// Executions without a "review" query are skipped.
+func (h *Service) appendReview(reviews []pendingReview, execution *s.WorkflowExecution, assignee string) ([]pendingReview, error) {
+	workflowID := execution.GetWorkflowId()
+	review, err := h.queryReview(workflowID, execution.GetRunId())
+	if _, noQuery := err.(*s.QueryFailedError); noQuery {
+		return reviews, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query review of %s: %w", workflowID, err)
+	}
+	// The review may have been decided since the execution was listed.
+	if review == nil || (assignee != "" && review.Assignee != assignee && review.Reviewer != assignee) {
+		return reviews, nil
+	}
+	return append(reviews, pendingReview{
+		Execution: workflows.Execution{WorkflowID: workflowID, RunID: execution.GetRunId()},
+		Review:    *review,
+	}), nil
+}
+
+// reviewHandler returns the handler sending a reviewer signal: claim, approve
+// or reject. The signal is only sent when the execution waits on a review for
+// the given action that the reviewer may decide on.
+func (h *Service) reviewHandler(signalName string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			data := workflows.ReviewSignal{}
+			err := json.NewDecoder(r.Body).Decode(&data)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if data.WorkflowId == "" || data.Action == "" || data.Reviewer == "" {
+				http.Error(w, "Missing workflowId, action or reviewer!", http.StatusBadRequest)
+				return
+			}
+
+			review, err := h.queryReview(data.WorkflowId, data.RunId)
+			if err != nil {
+				http.Error(w, "Error getting review!", http.StatusBadRequest)
+				return
+			}
+			if review == nil || review.Action != data.Action {
+				writeReviewConflict(w, "No open review for "+data.Action, review)
+				return
+			}
+			if review.Status == workflows.ReviewClaimed && review.Reviewer != data.Reviewer {
+				writeReviewConflict(w, "Review claimed by "+review.Reviewer, review)
+				return
+			}
+
+			err = h.cadenceAdapter.CadenceClient.SignalWorkflow(context.Background(), data.WorkflowId, data.RunId, signalName, data)
+			if err != nil {
+				http.Error(w, "Error signaling workflow!", http.StatusBadRequest)
+				return
+			}
+
+			h.logger.Info("Signaled review!", zap.String("WorkflowId", data.WorkflowId), zap.String("Signal", signalName), zap.String("Reviewer", data.Reviewer))
+
+			js, _ := json.Marshal("Success")
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(js)
+		} else {
+			_, _ = w.Write([]byte("Invalid Method!" + r.Method))
+		}
+	}
+}
+
+// reviewConflict is returned with a 409 when a reviewer signal does not fit
+// the open review.
+type reviewConflict struct {
+	Error  string                `json:"error"`
+	Review *workflows.ReviewTask `json:"review,omitempty"`
+}
+
+func writeReviewConflict(w http.ResponseWriter, message string, review *workflows.ReviewTask) {
+	js, _ := json.Marshal(reviewConflict{Error: message, Review: review})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	_, _ = w.Write(js)
+}
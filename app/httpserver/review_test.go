@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/worker/workflows"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/mocks"
+)
+
+// onReview answers the "review" query of workflowID with review, or fails it
+// with err.
+func onReview(cadenceClient *mocks.Client, workflowID string, review *workflows.ReviewTask, err error) {
+	isExecution := mock.MatchedBy(func(request *client.QueryWorkflowWithOptionsRequest) bool {
+		return request.WorkflowID == workflowID && request.QueryType == "review"
+	})
+	if err != nil {
+		cadenceClient.On("QueryWorkflowWithOptions", mock.Anything, isExecution).Return(nil, err)
+		return
+	}
+	js, _ := json.Marshal(review)
+	cadenceClient.On("QueryWorkflowWithOptions", mock.Anything, isExecution).
+		Return(&client.QueryWorkflowWithOptionsResponse{QueryResult: jsonValue(js)}, nil)
+}
+
+func executionInfo(workflowID string) *s.WorkflowExecutionInfo {
+	runID := workflowID + "-run"
+	return &s.WorkflowExecutionInfo{Execution: &s.WorkflowExecution{WorkflowId: &workflowID, RunId: &runID}}
+}
+
+func listReviews(service *Service, target string) (*httptest.ResponseRecorder, []pendingReview) {
+	recorder := httptest.NewRecorder()
+	service.listReviews(recorder, httptest.NewRequest("GET", target, nil))
+	var reviews []pendingReview
+	_ = json.Unmarshal(recorder.Body.Bytes(), &reviews)
+	return recorder, reviews
+}
+
+func TestListReviewsWithoutSearchAttributes(t *testing.T) {
+	service, cadenceClient := newTestService(t)
+	cadenceClient.On("ListOpenWorkflow", mock.Anything, mock.MatchedBy(func(request *s.ListOpenWorkflowExecutionsRequest) bool {
+		return request.StartTimeFilter != nil
+	})).Return(&s.ListOpenWorkflowExecutionsResponse{Executions: []*s.WorkflowExecutionInfo{
+		executionInfo("assigned"),
+		executionInfo("claimed"),
+		executionInfo("other-team"),
+		executionInfo("no-review"),
+		executionInfo("legacy"),
+	}}, nil)
+	onReview(cadenceClient, "assigned", &workflows.ReviewTask{Action: "interview", Assignee: "reviewer-1", Status: workflows.ReviewPending}, nil)
+	onReview(cadenceClient, "claimed", &workflows.ReviewTask{Action: "interview", Assignee: "interviewers", Reviewer: "reviewer-1", Status: workflows.ReviewClaimed}, nil)
+	onReview(cadenceClient, "other-team", &workflows.ReviewTask{Action: "interview", Assignee: "interviewers", Status: workflows.ReviewPending}, nil)
+	onReview(cadenceClient, "no-review", nil, nil)
+	// Executions without a review query are skipped.
+	onReview(cadenceClient, "legacy", nil, &s.QueryFailedError{Message: "unknown queryType review"})
+
+	recorder, reviews := listReviews(service, "/api/reviews?assignee=reviewer-1")
+
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.Len(t, reviews, 2)
+	require.Equal(t, "assigned", reviews[0].WorkflowID)
+	require.Equal(t, "claimed", reviews[1].WorkflowID)
+	require.Equal(t, workflows.ReviewClaimed, reviews[1].Review.Status)
+}
+
+func TestListReviewsReportsQueryErrors(t *testing.T) {
+	service, cadenceClient := newTestService(t)
+	cadenceClient.On("ListOpenWorkflow", mock.Anything, mock.Anything).
+		Return(&s.ListOpenWorkflowExecutionsResponse{Executions: []*s.WorkflowExecutionInfo{executionInfo("unreachable")}}, nil)
+	onReview(cadenceClient, "unreachable", nil, errors.New("timeout"))
+
+	recorder, _ := listReviews(service, "/api/reviews")
+
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestListReviewsBySearchAttributes(t *testing.T) {
+	service, cadenceClient := newTestService(t)
+	service.reviewSearchAttributes = true
+	cadenceClient.On("ListWorkflow", mock.Anything, mock.MatchedBy(func(request *s.ListWorkflowExecutionsRequest) bool {
+		return request.GetQuery() == reviewQuery("reviewer-1")
+	})).Return(&s.ListWorkflowExecutionsResponse{Executions: []*s.WorkflowExecutionInfo{executionInfo("assigned")}}, nil)
+	onReview(cadenceClient, "assigned", &workflows.ReviewTask{Action: "interview", Assignee: "reviewer-1", Status: workflows.ReviewPending}, nil)
+
+	recorder, reviews := listReviews(service, "/api/reviews?assignee=reviewer-1")
+
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.Len(t, reviews, 1)
+	require.Equal(t, "assigned-run", reviews[0].RunID)
+}
+
+func TestListReviewsRejectsQuotedAssignee(t *testing.T) {
+	service, _ := newTestService(t)
+
+	recorder, _ := listReviews(service, "/api/reviews?assignee=x'%20or%20'1")
+
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
@@ -0,0 +1,163 @@
+// app/httpserver/status.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/worker/workflows"
+
+	"go.uber.org/zap"
+)
+
+// rootJourney is the journey whose execution is the root of an applicant's
+// status tree when the status is requested by applicant ID.
+const rootJourney = "onboarding"
+
+// queryStatus returns the status tree of an execution. The "state" query of
+// an execution holds its own stages; executions started as children of a step
+// are queried in turn and added as children of the execution.
+func (h *Service) queryStatus(execution workflows.Execution) (workflows.JourneyStatus, error) {
+	return h.walkStatus(execution, map[string]bool{})
+}
+
+func (h *Service) walkStatus(execution workflows.Execution, seen map[string]bool) (workflows.JourneyStatus, error) {
+	status := workflows.JourneyStatus{}
+	state, err := h.queryState(execution)
+	if err != nil {
+		return status, err
+	}
+	if err := json.Unmarshal(state, &status); err != nil {
+		return status, err
+	}
+	status.Execution = execution
+	seen[execution.WorkflowID] = true
+
+	for _, step := range status.Steps {
+		// Steps of workflows written before the step runner hold the IDs of
+		// their own execution.
+		if step.WorkflowID == nil || seen[*step.WorkflowID] {
+			continue
+		}
+		child := workflows.Execution{WorkflowID: *step.WorkflowID}
+		if step.RunID != nil {
+			child.RunID = *step.RunID
+		}
+		childStatus, err := h.walkStatus(child, seen)
+		if err != nil {
+			return status, fmt.Errorf("child %s: %w", child.WorkflowID, err)
+		}
+		status.Children = append(status.Children, childStatus)
+	}
+	return status, nil
+}
+
+// queryActive returns the status of the execution waiting on the applicant in
+// the status tree under root.
+func (h *Service) queryActive(root workflows.Execution) (workflows.JourneyStatus, error) {
+	status, err := h.queryStatus(root)
+	return activeStatus(status), err
+}
+
+// activeStatus returns the node of a status tree for the execution waiting on
+// the applicant. It follows the child started by the current step of each
+// execution down to one whose current step runs in the execution itself.
+func activeStatus(status workflows.JourneyStatus) workflows.JourneyStatus {
+	for status.Current.WorkflowID != nil {
+		child, ok := findChild(status, *status.Current.WorkflowID)
+		if !ok {
+			break
+		}
+		status = child
+	}
+	return status
+}
+
+func findChild(status workflows.JourneyStatus, workflowID string) (workflows.JourneyStatus, bool) {
+	for _, child := range status.Children {
+		if child.WorkflowID == workflowID {
+			return child, true
+		}
+	}
+	return workflows.JourneyStatus{}, false
+}
+
+// findExecutionStatus returns the node of an execution anywhere in a status
+// tree.
+func findExecutionStatus(status workflows.JourneyStatus, workflowID string) (workflows.JourneyStatus, bool) {
+	if status.WorkflowID == workflowID {
+		return status, true
+	}
+	for _, child := range status.Children {
+		if child.WorkflowID == "" {
+			continue
+		}
+		if found, ok := findExecutionStatus(child, workflowID); ok {
+			return found, true
+		}
+	}
+	return workflows.JourneyStatus{}, false
+}
+
+// rootExecution returns the execution a request names: the one with its
+// workflow ID, or else the applicant's journey of the given type, onboarding
+// by default. It returns ok false when the request names neither.
+func rootExecution(workflowID string, runID string, applicantID string, journey string) (workflows.Execution, bool) {
+	if workflowID != "" {
+		return workflows.Execution{WorkflowID: workflowID, RunID: runID}, true
+	}
+	if applicantID == "" {
+		return workflows.Execution{}, false
+	}
+	if journey == "" {
+		journey = rootJourney
+	}
+	return workflows.Execution{WorkflowID: workflows.WorkflowID(journey, applicantID)}, true
+}
+
+// statusRequest identifies the root of a status tree: an execution, or an
+// applicant whose journey execution is the root.
+type statusRequest struct {
+	WorkflowId  string `json:"workflowId"`
+	RunId       string `json:"runId"`
+	ApplicantId string `json:"applicantId"`
+	Journey     string `json:"journey"`
+}
+
+// getStatus handles GET /api/status?workflowId=&runId= and
+// GET /api/status?applicantId=&journey=. It returns the status tree of the
+// execution, with the states of its child executions and stages under it. The
+// tree can also be requested with a POST of the same fields as JSON.
+func (h *Service) getStatus(w http.ResponseWriter, r *http.Request) {
+	data := statusRequest{}
+	if r.Method == "POST" {
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else if r.Method == "GET" {
+		query := r.URL.Query()
+		data = statusRequest{WorkflowId: query.Get("workflowId"), RunId: query.Get("runId"), ApplicantId: query.Get("applicantId"), Journey: query.Get("journey")}
+	} else {
+		_, _ = w.Write([]byte("Invalid Method!" + r.Method))
+		return
+	}
+
+	execution, ok := rootExecution(data.WorkflowId, data.RunId, data.ApplicantId, data.Journey)
+	if !ok {
+		http.Error(w, "Missing workflowId or applicantId!", http.StatusBadRequest)
+		return
+	}
+
+	status, err := h.queryStatus(execution)
+	if err != nil {
+		h.logger.Error("Query status failed.", zap.String("WorkflowId", execution.WorkflowID), zap.Error(err))
+		http.Error(w, "Error getting status workflow!", http.StatusBadRequest)
+		return
+	}
+
+	js, _ := json.Marshal(status)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(js)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/worker/workflows"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusIncludesChildExecutions(t *testing.T) {
+	service, cadenceClient := newTestService(t)
+	rootID := workflows.WorkflowID("onboarding", "applicant-1")
+	childID := workflows.WorkflowID("orientation", "applicant-1")
+	root := workflows.WorkflowState{Steps: []workflows.WorkflowStep{
+		{Action: "orientation", Index: 1, Status: workflows.StatusInProgress, WorkflowID: &childID},
+	}}
+	root.Current = root.Steps[0]
+	onState(cadenceClient, rootID, root)
+	onState(cadenceClient, childID, testState("contact", 1))
+
+	recorder := httptest.NewRecorder()
+	service.getStatus(recorder, httptest.NewRequest("GET", "/api/status?applicantId=applicant-1", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var status workflows.JourneyStatus
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &status))
+	require.Equal(t, rootID, status.WorkflowID)
+	require.Len(t, status.Children, 1)
+	require.Equal(t, childID, status.Children[0].WorkflowID)
+	require.Equal(t, "contact", status.Children[0].Current.Action)
+	require.Equal(t, childID, activeStatus(status).WorkflowID)
+}
+
+func TestStatusWithoutExecution(t *testing.T) {
+	service, _ := newTestService(t)
+
+	recorder := httptest.NewRecorder()
+	service.getStatus(recorder, httptest.NewRequest("GET", "/api/status", nil))
+
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
@@ -0,0 +1,142 @@
+// app/httpserver/upload.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/adapters/objectstore"
+	"github.com/BhanuChandraAraveti/cadence-example/app/worker/workflows"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/client"
+	"go.uber.org/zap"
+)
+
+// queryUpload returns the upload task an execution waits on, or nil when it
+// is not waiting on an upload.
+func (h *Service) queryUpload(workflowID string, runID string) (*workflows.UploadTask, error) {
+	resp, err := h.cadenceAdapter.CadenceClient.QueryWorkflowWithOptions(context.Background(), &client.QueryWorkflowWithOptionsRequest{
+		WorkflowID:            workflowID,
+		RunID:                 runID,
+		QueryType:             "upload",
+		QueryConsistencyLevel: s.QueryConsistencyLevelStrong.Ptr(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var upload *workflows.UploadTask
+	err = resp.QueryResult.Get(&upload)
+	return upload, err
+}
+
+// openUpload decodes an upload request and returns it with the upload task it
+// is for. It writes the error response and returns ok false when the
+// execution is not waiting on an upload for the requested action.
+func (h *Service) openUpload(w http.ResponseWriter, r *http.Request) (workflows.UploadSignal, bool) {
+	data := workflows.UploadSignal{}
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return data, false
+	}
+	if data.WorkflowId == "" || data.Action == "" {
+		http.Error(w, "Missing workflowId or action!", http.StatusBadRequest)
+		return data, false
+	}
+
+	upload, err := h.queryUpload(data.WorkflowId, data.RunId)
+	if err != nil {
+		http.Error(w, "Error getting upload!", http.StatusBadRequest)
+		return data, false
+	}
+	if upload == nil || upload.Action != data.Action {
+		writeUploadConflict(w, "No open upload for "+data.Action, upload)
+		return data, false
+	}
+	return data, true
+}
+
+// requestUpload handles POST /api/uploads. It returns a pre-signed target the
+// applicant uploads the file of the upload step to.
+func (h *Service) requestUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		data, ok := h.openUpload(w, r)
+		if !ok {
+			return
+		}
+
+		key := workflows.UploadPrefix(data.WorkflowId, data.Action) + strconv.FormatInt(time.Now().UnixNano(), 10)
+		target, err := h.objectStore.PresignUpload(key)
+		if err != nil {
+			h.logger.Error("Presign upload failed.", zap.Error(err))
+			http.Error(w, "Error creating upload!", http.StatusBadRequest)
+			return
+		}
+
+		js, _ := json.Marshal(target)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(js)
+	} else {
+		_, _ = w.Write([]byte("Invalid Method!" + r.Method))
+	}
+}
+
+// completeUpload handles POST /api/uploads/complete. Once the file is in the
+// store it sends the upload-complete signal, which starts the upload
+// pipeline.
+func (h *Service) completeUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		data, ok := h.openUpload(w, r)
+		if !ok {
+			return
+		}
+		if !strings.HasPrefix(data.Key, workflows.UploadPrefix(data.WorkflowId, data.Action)) {
+			http.Error(w, "Invalid key!", http.StatusBadRequest)
+			return
+		}
+		_, err := h.objectStore.Stat(context.Background(), data.Key)
+		if errors.Is(err, objectstore.ErrNotFound) {
+			http.Error(w, "Nothing uploaded to "+data.Key+"!", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			h.logger.Error("Stat upload failed.", zap.Error(err))
+			http.Error(w, "Error getting upload!", http.StatusBadRequest)
+			return
+		}
+
+		err = h.cadenceAdapter.CadenceClient.SignalWorkflow(context.Background(), data.WorkflowId, data.RunId, workflows.UploadCompleteSignalName, data)
+		if err != nil {
+			http.Error(w, "Error signaling workflow!", http.StatusBadRequest)
+			return
+		}
+
+		h.logger.Info("Signaled upload!", zap.String("WorkflowId", data.WorkflowId), zap.String("Key", data.Key))
+
+		js, _ := json.Marshal("Success")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(js)
+	} else {
+		_, _ = w.Write([]byte("Invalid Method!" + r.Method))
+	}
+}
+
+// uploadConflict is returned with a 409 when an upload request does not fit
+// the open upload.
+type uploadConflict struct {
+	Error  string                `json:"error"`
+	Upload *workflows.UploadTask `json:"upload,omitempty"`
+}
+
+func writeUploadConflict(w http.ResponseWriter, message string, upload *workflows.UploadTask) {
+	js, _ := json.Marshal(uploadConflict{Error: message, Upload: upload})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	_, _ = w.Write(js)
+}
@@ -5,6 +5,10 @@ import (
 	"fmt"
 
 	"github.com/BhanuChandraAraveti/cadence-example/app/adapters/cadenceAdapter"
+	"github.com/BhanuChandraAraveti/cadence-example/app/adapters/notifieradapter"
+	"github.com/BhanuChandraAraveti/cadence-example/app/adapters/objectstore"
+	"github.com/BhanuChandraAraveti/cadence-example/app/adapters/profileadapter"
+	"github.com/BhanuChandraAraveti/cadence-example/app/adapters/videoprobe"
 	"github.com/BhanuChandraAraveti/cadence-example/app/config"
 	"github.com/BhanuChandraAraveti/cadence-example/app/worker/workflows"
 
@@ -31,6 +35,42 @@ func main() {
    fmt.Println("Starting Worker..")
    var appConfig config.AppConfig
    appConfig.Setup()
+
+   agreements, err := workflows.LoadAgreements(appConfig.AgreementsPath)
+   if err != nil {
+      appConfig.Logger.Fatal("Failed to load agreements.", zap.Error(err))
+   }
+   workflows.RegisterAgreements(agreements)
+
+   journeys, err := workflows.LoadJourneys(appConfig.JourneysPath)
+   if err != nil {
+      appConfig.Logger.Fatal("Failed to load journey definitions.", zap.Error(err))
+   }
+   if err := workflows.RegisterJourneys(journeys); err != nil {
+      appConfig.Logger.Fatal("Failed to register journeys.", zap.Error(err))
+   }
+
+   if err := workflows.SetActivityProfiles(appConfig.ActivityProfiles, appConfig.Activities); err != nil {
+      appConfig.Logger.Fatal("Invalid activity profiles.", zap.Error(err))
+   }
+   workflows.SetProfileBackend(profileadapter.NewClient(appConfig.Profile))
+   if appConfig.Notifier.WebhookURL != "" {
+      workflows.SetNotifier(notifieradapter.NewWebhook(appConfig.Notifier))
+   }
+   workflows.SetObjectStore(objectstore.NewLocal(appConfig.ObjectStore))
+   if appConfig.FFprobePath != "" {
+      workflows.SetVideoProbe(videoprobe.NewFFprobe(appConfig.FFprobePath))
+   }
+   workflows.SetReviewSearchAttributes(appConfig.ReviewSearchAttributes)
+   if scoring := appConfig.Scoring; scoring != nil {
+      workflows.SetScorer(workflows.RuleScorer{
+         Keywords:  scoring.Keywords,
+         MinWords:  scoring.MinWords,
+         MaxWords:  scoring.MaxWords,
+         AnswerKey: scoring.AnswerKey,
+      })
+   }
+
    var cadenceClient cadenceAdapter.CadenceAdapter
    cadenceClient.Setup(&appConfig.Cadence)
 
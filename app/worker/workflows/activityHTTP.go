@@ -0,0 +1,160 @@
+package workflows
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"go.uber.org/cadence"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+)
+
+// Reasons of the errors activity HTTP calls fail with. Server errors, 429s and
+// transport failures are retriable; other 4xx responses are not, since the
+// server would reject the request again.
+const (
+	ReasonHTTPRetriable   = "http-retriable"
+	ReasonHTTPClientError = "http-client-error"
+)
+
+// heartbeatInterval is how often an activity heartbeats while a call is in
+// flight, well within the heartbeat timeout of the default profile.
+const heartbeatInterval = 5 * time.Second
+
+// maxResponseBody caps the response body kept from a call, since completed
+// calls are carried in the heartbeat details.
+const maxResponseBody = 64 << 10
+
+// activityHTTPClient has no timeout of its own: requests are bounded by the
+// activity context, which is cancelled on timeout or cancellation.
+var activityHTTPClient = &http.Client{}
+
+// CallResult is the response to an activity call.
+type CallResult struct {
+	StatusCode int    `json:"status_code,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Body       []byte `json:"body,omitempty"`
+}
+
+// CallProgress is the heartbeat detail of an activity making calls: the calls
+// it completed, by key, and the one in flight. A retried attempt picks it up
+// and does not repeat completed calls.
+type CallProgress struct {
+	Completed map[string]CallResult `json:"completed,omitempty"`
+	Current   string                `json:"current,omitempty"`
+	Started   time.Time             `json:"started,omitempty"`
+}
+
+// activityCalls makes the calls of one activity attempt.
+type activityCalls struct {
+	ctx      context.Context
+	progress CallProgress
+}
+
+// newActivityCalls starts tracking the calls of an activity attempt, resuming
+// from the heartbeat details of the previous attempt if there is one.
+func newActivityCalls(ctx context.Context) *activityCalls {
+	a := &activityCalls{ctx: ctx}
+	if activity.HasHeartbeatDetails(ctx) {
+		if err := activity.GetHeartbeatDetails(ctx, &a.progress); err != nil {
+			activity.GetLogger(ctx).Warn("Ignoring heartbeat details.", zap.Error(err))
+			a.progress = CallProgress{}
+		}
+	}
+	if a.progress.Completed == nil {
+		a.progress.Completed = map[string]CallResult{}
+	}
+	return a
+}
+
+// Get sends a GET request to url as the call named key.
+func (a *activityCalls) Get(key string, url string) (CallResult, error) {
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return CallResult{}, cadence.NewCustomError(ReasonHTTPClientError, err.Error())
+	}
+	return a.Do(key, request)
+}
+
+// Do sends request as the call named key and classifies the response status.
+func (a *activityCalls) Do(key string, request *http.Request) (CallResult, error) {
+	return a.run(key, func() (CallResult, error) {
+		response, err := activityHTTPClient.Do(request.WithContext(a.ctx))
+		if err != nil {
+			return CallResult{}, cadence.NewCustomError(ReasonHTTPRetriable, err.Error())
+		}
+		defer response.Body.Close()
+
+		result := CallResult{StatusCode: response.StatusCode, Status: response.Status}
+		result.Body, err = ioutil.ReadAll(io.LimitReader(response.Body, maxResponseBody))
+		if err != nil {
+			return result, cadence.NewCustomError(ReasonHTTPRetriable, err.Error())
+		}
+		return result, statusError(response.StatusCode, response.Status)
+	})
+}
+
+// run makes the call named key unless an earlier attempt completed it,
+// heartbeating while it is in flight. If the activity is cancelled or times
+// out, the context error is returned.
+func (a *activityCalls) run(key string, call func() (CallResult, error)) (CallResult, error) {
+	logger := activity.GetLogger(a.ctx)
+	if result, ok := a.progress.Completed[key]; ok {
+		logger.Info("Call completed by an earlier attempt.", zap.String("call", key))
+		return result, nil
+	}
+
+	a.progress.Current = key
+	a.progress.Started = time.Now()
+	activity.RecordHeartbeat(a.ctx, a.progress)
+
+	type outcome struct {
+		result CallResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := call()
+		done <- outcome{result, err}
+	}()
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			activity.RecordHeartbeat(a.ctx, a.progress)
+		case <-a.ctx.Done():
+			// The call is left to finish on its own; done is buffered.
+			a.progress.Current = ""
+			return CallResult{}, a.ctx.Err()
+		case o := <-done:
+			a.progress.Current = ""
+			if a.ctx.Err() != nil {
+				return o.result, a.ctx.Err()
+			}
+			if o.err != nil {
+				logger.Error("Call failed.", zap.String("call", key), zap.Error(o.err))
+				return o.result, o.err
+			}
+			a.progress.Completed[key] = o.result
+			activity.RecordHeartbeat(a.ctx, a.progress)
+			return o.result, nil
+		}
+	}
+}
+
+// statusError maps an HTTP response status to an activity error.
+func statusError(code int, status string) error {
+	switch {
+	case code < 300:
+		return nil
+	case code == http.StatusTooManyRequests || code >= 500:
+		return cadence.NewCustomError(ReasonHTTPRetriable, status)
+	default:
+		return cadence.NewCustomError(ReasonHTTPClientError, status)
+	}
+}
@@ -0,0 +1,125 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/cadence"
+	"go.uber.org/cadence/testsuite"
+)
+
+// getActivity makes one call to url named "call".
+func getActivity(ctx context.Context, url string) (CallResult, error) {
+	return newActivityCalls(ctx).Get("call", url)
+}
+
+// statusServer answers /<code> with that status and counts the requests.
+func statusServer(t *testing.T) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		code, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		w.WriteHeader(code)
+		_, _ = w.Write([]byte("body"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func newCallsEnvironment() *testsuite.TestActivityEnvironment {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(getActivity)
+	return env
+}
+
+// reason returns the reason of a custom error, or "" for other errors.
+func reason(err error) string {
+	var customErr *cadence.CustomError
+	if errors.As(err, &customErr) {
+		return customErr.Reason()
+	}
+	return ""
+}
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		code   int
+		reason string
+	}{
+		{http.StatusOK, ""},
+		{http.StatusNoContent, ""},
+		{http.StatusMovedPermanently, ReasonHTTPClientError},
+		{http.StatusBadRequest, ReasonHTTPClientError},
+		{http.StatusNotFound, ReasonHTTPClientError},
+		{http.StatusTooManyRequests, ReasonHTTPRetriable},
+		{http.StatusInternalServerError, ReasonHTTPRetriable},
+		{http.StatusServiceUnavailable, ReasonHTTPRetriable},
+	}
+	for _, test := range tests {
+		err := statusError(test.code, http.StatusText(test.code))
+		if test.reason == "" {
+			require.NoError(t, err, test.code)
+			continue
+		}
+		require.Equal(t, test.reason, reason(err), test.code)
+	}
+}
+
+func TestActivityCallsClassifyFailures(t *testing.T) {
+	server, _ := statusServer(t)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name   string
+		url    string
+		reason string
+	}{
+		{"rejected", server.URL + "/422", ReasonHTTPClientError},
+		{"throttled", server.URL + "/429", ReasonHTTPRetriable},
+		{"server error", server.URL + "/502", ReasonHTTPRetriable},
+		{"unreachable", closed.URL + "/200", ReasonHTTPRetriable},
+		{"invalid URL", "http://%zz", ReasonHTTPClientError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := newCallsEnvironment().ExecuteActivity(getActivity, test.url)
+
+			require.Error(t, err)
+			require.Equal(t, test.reason, reason(err), "%v", err)
+		})
+	}
+}
+
+func TestActivityCallsReturnResponse(t *testing.T) {
+	server, _ := statusServer(t)
+
+	value, err := newCallsEnvironment().ExecuteActivity(getActivity, server.URL+"/201")
+
+	require.NoError(t, err)
+	var result CallResult
+	require.NoError(t, value.Get(&result))
+	require.Equal(t, CallResult{StatusCode: 201, Status: "201 Created", Body: []byte("body")}, result)
+}
+
+func TestActivityCallsSkipCallsOfEarlierAttempts(t *testing.T) {
+	server, requests := statusServer(t)
+	env := newCallsEnvironment()
+	env.SetHeartbeatDetails(CallProgress{Completed: map[string]CallResult{"call": {StatusCode: 200, Status: "200 OK"}}})
+
+	value, err := env.ExecuteActivity(getActivity, server.URL+"/500")
+
+	require.NoError(t, err)
+	var result CallResult
+	require.NoError(t, value.Get(&result))
+	require.Equal(t, "200 OK", result.Status)
+	require.Zero(t, atomic.LoadInt32(requests))
+}
@@ -0,0 +1,112 @@
+package workflows
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/config"
+
+	"go.uber.org/cadence"
+	"go.uber.org/cadence/workflow"
+)
+
+// activityProfiles are the named activity options activities run with. The
+// "default" profile is used for activities not in activityProfileNames.
+var activityProfiles = map[string]workflow.ActivityOptions{
+	"default": activityOptions,
+	"backend": profileActivityOptions,
+	"upload":  uploadActivityOptions,
+}
+
+// activityProfileNames maps activity names to the profile they run with.
+var activityProfileNames = map[string]string{
+	"persist-profile": "backend",
+	"validate-video":  "upload",
+	"store-video":     "upload",
+	"attach-video":    "backend",
+}
+
+// otherActivities are the names of the activities that are not step
+// activities, as used in activityProfileNames.
+var otherActivities = map[string]bool{
+	"persist-profile": true,
+	"send-reminder":   true,
+	"eval-sop":        true,
+	"eval-cet":        true,
+	"validate-video":  true,
+	"store-video":     true,
+	"attach-video":    true,
+
+	"present-agreement": true,
+	"sign-agreement":    true,
+}
+
+// withActivityProfile returns ctx with the options of the profile the named
+// activity runs with.
+func withActivityProfile(ctx workflow.Context, activityName string) workflow.Context {
+	profile, ok := activityProfileNames[activityName]
+	if !ok {
+		profile = "default"
+	}
+	return workflow.WithActivityOptions(ctx, activityProfiles[profile])
+}
+
+// SetActivityProfiles installs the activity profiles from the config, replacing
+// built-in profiles of the same name, and the activities that use them. It
+// fails if a profile is incomplete or an activity is unknown or references an
+// unknown profile. Retry policies never retry ReasonHTTPClientError, whether
+// or not the profile lists it.
+func SetActivityProfiles(profiles map[string]config.ActivityProfileConfig, activities map[string]string) error {
+	for name, profile := range profiles {
+		options, err := toActivityOptions(profile)
+		if err != nil {
+			return fmt.Errorf("activity profile %q: %w", name, err)
+		}
+		activityProfiles[name] = options
+	}
+	for activityName, profile := range activities {
+		if _, ok := stepActivities[activityName]; !ok && !otherActivities[activityName] {
+			return fmt.Errorf("unknown activity %q", activityName)
+		}
+		if _, ok := activityProfiles[profile]; !ok {
+			return fmt.Errorf("activity %q: unknown profile %q", activityName, profile)
+		}
+		activityProfileNames[activityName] = profile
+	}
+	activityOptions = activityProfiles["default"]
+	return nil
+}
+
+func toActivityOptions(profile config.ActivityProfileConfig) (workflow.ActivityOptions, error) {
+	if profile.ScheduleToStartTimeout <= 0 || profile.StartToCloseTimeout <= 0 {
+		return workflow.ActivityOptions{}, errors.New("scheduleToStartTimeout and startToCloseTimeout are required")
+	}
+	options := workflow.ActivityOptions{
+		ScheduleToStartTimeout: profile.ScheduleToStartTimeout,
+		StartToCloseTimeout:    profile.StartToCloseTimeout,
+		HeartbeatTimeout:       profile.HeartbeatTimeout,
+	}
+
+	retry := profile.Retry
+	if retry == nil {
+		return options, nil
+	}
+	if retry.InitialInterval <= 0 {
+		return options, errors.New("retry needs an initialInterval")
+	}
+	if retry.ExpirationInterval <= 0 && retry.MaximumAttempts <= 0 {
+		return options, errors.New("retry needs an expirationInterval or maximumAttempts")
+	}
+	options.RetryPolicy = &cadence.RetryPolicy{
+		InitialInterval:          retry.InitialInterval,
+		BackoffCoefficient:       retry.BackoffCoefficient,
+		MaximumInterval:          retry.MaximumInterval,
+		ExpirationInterval:       retry.ExpirationInterval,
+		MaximumAttempts:          retry.MaximumAttempts,
+		NonRetriableErrorReasons: append([]string{ReasonHTTPClientError}, retry.NonRetriableErrorReasons...),
+	}
+	if options.RetryPolicy.BackoffCoefficient == 0 {
+		options.RetryPolicy.BackoffCoefficient = 2.0
+	}
+	return options, nil
+}
@@ -0,0 +1,110 @@
+package workflows
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/config"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/cadence/workflow"
+)
+
+// restoreActivityProfiles restores the activity profiles after a test that
+// sets them.
+func restoreActivityProfiles(t *testing.T) {
+	profiles := map[string]workflow.ActivityOptions{}
+	for name, options := range activityProfiles {
+		profiles[name] = options
+	}
+	names := map[string]string{}
+	for activityName, profile := range activityProfileNames {
+		names[activityName] = profile
+	}
+	defaults := activityOptions
+	t.Cleanup(func() {
+		activityProfiles, activityProfileNames, activityOptions = profiles, names, defaults
+	})
+}
+
+func TestSetActivityProfiles(t *testing.T) {
+	restoreActivityProfiles(t)
+
+	err := SetActivityProfiles(map[string]config.ActivityProfileConfig{
+		"default": {ScheduleToStartTimeout: time.Minute, StartToCloseTimeout: 2 * time.Minute},
+		"quick": {
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    15 * time.Second,
+			Retry: &config.RetryConfig{
+				InitialInterval:          time.Second,
+				MaximumAttempts:          3,
+				NonRetriableErrorReasons: []string{"invalid-video"},
+			},
+		},
+	}, map[string]string{"template": "quick", "eval-sop": "backend"})
+
+	require.NoError(t, err)
+	require.Equal(t, "quick", activityProfileNames["template"])
+	require.Equal(t, "backend", activityProfileNames["eval-sop"])
+	require.Equal(t, "upload", activityProfileNames["validate-video"], "built-in assignments are kept")
+	require.Equal(t, 2*time.Minute, activityOptions.StartToCloseTimeout)
+	require.Nil(t, activityOptions.RetryPolicy)
+
+	quick := activityProfiles["quick"]
+	require.Equal(t, 15*time.Second, quick.StartToCloseTimeout)
+	require.Equal(t, 2.0, quick.RetryPolicy.BackoffCoefficient, "the backoff defaults to doubling")
+	require.Equal(t, int32(3), quick.RetryPolicy.MaximumAttempts)
+	require.Equal(t, []string{ReasonHTTPClientError, "invalid-video"}, quick.RetryPolicy.NonRetriableErrorReasons)
+}
+
+func TestSetActivityProfilesValidates(t *testing.T) {
+	valid := config.ActivityProfileConfig{ScheduleToStartTimeout: time.Minute, StartToCloseTimeout: time.Minute}
+	tests := []struct {
+		name       string
+		profiles   map[string]config.ActivityProfileConfig
+		activities map[string]string
+		err        string
+	}{
+		{
+			name:     "missing timeouts",
+			profiles: map[string]config.ActivityProfileConfig{"slow": {ScheduleToStartTimeout: time.Minute}},
+			err:      `activity profile "slow": scheduleToStartTimeout and startToCloseTimeout are required`,
+		},
+		{
+			name: "retry without initial interval",
+			profiles: map[string]config.ActivityProfileConfig{"slow": {
+				ScheduleToStartTimeout: time.Minute,
+				StartToCloseTimeout:    time.Minute,
+				Retry:                  &config.RetryConfig{MaximumAttempts: 3},
+			}},
+			err: `activity profile "slow": retry needs an initialInterval`,
+		},
+		{
+			name: "unbounded retry",
+			profiles: map[string]config.ActivityProfileConfig{"slow": {
+				ScheduleToStartTimeout: time.Minute,
+				StartToCloseTimeout:    time.Minute,
+				Retry:                  &config.RetryConfig{InitialInterval: time.Second},
+			}},
+			err: `activity profile "slow": retry needs an expirationInterval or maximumAttempts`,
+		},
+		{
+			name:       "unknown activity",
+			profiles:   map[string]config.ActivityProfileConfig{"slow": valid},
+			activities: map[string]string{"teleport": "slow"},
+			err:        `unknown activity "teleport"`,
+		},
+		{
+			name:       "unknown profile",
+			activities: map[string]string{"template": "slow"},
+			err:        `activity "template": unknown profile "slow"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			restoreActivityProfiles(t)
+
+			require.EqualError(t, SetActivityProfiles(test.profiles, test.activities), test.err)
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package workflows
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/adapters/objectstore"
+
+	"github.com/spf13/viper"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+)
+
+// This is registration process where you register all your workflows
+// and activity function handlers.
+func init() {
+	activity.Register(presentAgreementActivity)
+	activity.Register(signAgreementActivity)
+}
+
+// AgreementTemplate is a named, versioned agreement text loaded from the
+// agreements directory.
+type AgreementTemplate struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+	Title   string `json:"title"`
+	Text    string `json:"text"`
+}
+
+// Hash returns the SHA-256 of the agreement text.
+func (t AgreementTemplate) Hash() string {
+	sum := sha256.Sum256([]byte(t.Text))
+	return hex.EncodeToString(sum[:])
+}
+
+// AgreementDefinition turns a step into an e-sign step for the latest version
+// of the named agreement. The applicant accepts it by submitting "accepted"
+// true and the presented "agreement_version".
+type AgreementDefinition struct {
+	Name string `json:"name"`
+}
+
+// ClientInfo describes the client a submission came from. It is filled in by
+// the HTTP server, not by the applicant.
+type ClientInfo struct {
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// AgreementRecord records the agreement version presented in a step and its
+// acceptance.
+type AgreementRecord struct {
+	Action    string     `json:"action"`
+	Name      string     `json:"name"`
+	Version   int        `json:"version"`
+	Title     string     `json:"title,omitempty"`
+	Hash      string     `json:"hash"`
+	Presented time.Time  `json:"presented"`
+	Accepted  *time.Time `json:"accepted,omitempty"`
+	IP        string     `json:"ip,omitempty"`
+	UserAgent string     `json:"user_agent,omitempty"`
+	// RecordKey is where the signed record is stored and RecordHash is its
+	// SHA-256.
+	RecordKey  string `json:"record_key,omitempty"`
+	RecordHash string `json:"record_hash,omitempty"`
+}
+
+// SignedAgreement is the signed-record artifact stored for an accepted
+// agreement. It holds the full text the applicant accepted.
+type SignedAgreement struct {
+	ApplicantID string    `json:"applicant_id"`
+	WorkflowID  string    `json:"workflow_id"`
+	RunID       string    `json:"run_id"`
+	Action      string    `json:"action"`
+	Name        string    `json:"name"`
+	Version     int       `json:"version"`
+	Title       string    `json:"title"`
+	Text        string    `json:"text"`
+	Hash        string    `json:"hash"`
+	Accepted    time.Time `json:"accepted"`
+	IP          string    `json:"ip,omitempty"`
+	UserAgent   string    `json:"user_agent,omitempty"`
+}
+
+// agreements holds the templates registered at worker startup, by name and
+// version.
+var agreements = map[string]map[int]AgreementTemplate{}
+
+// LoadAgreements reads every YAML/JSON agreement template in dir. Each file
+// holds one version of an agreement; all versions are returned.
+func LoadAgreements(dir string) ([]AgreementTemplate, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var templates []AgreementTemplate
+	for _, file := range files {
+		ext := strings.TrimPrefix(filepath.Ext(file.Name()), ".")
+		if file.IsDir() || (ext != "yml" && ext != "yaml" && ext != "json") {
+			continue
+		}
+
+		v := viper.New()
+		v.SetConfigFile(filepath.Join(dir, file.Name()))
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name(), err)
+		}
+		var template AgreementTemplate
+		if err := v.Unmarshal(&template); err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name(), err)
+		}
+		if template.Name == "" || template.Text == "" {
+			return nil, fmt.Errorf("%s: agreement without name or text", file.Name())
+		}
+		if template.Version < 1 {
+			return nil, fmt.Errorf("%s: agreement %q needs a version of at least 1", file.Name(), template.Name)
+		}
+		key := fmt.Sprintf("%s@%d", template.Name, template.Version)
+		if seen[key] {
+			return nil, fmt.Errorf("%s: agreement %q version %d defined twice", file.Name(), template.Name, template.Version)
+		}
+		seen[key] = true
+		templates = append(templates, template)
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Name != templates[j].Name {
+			return templates[i].Name < templates[j].Name
+		}
+		return templates[i].Version < templates[j].Version
+	})
+	return templates, nil
+}
+
+// RegisterAgreements registers the given templates. It must be called before
+// RegisterJourneys, which checks that agreement steps name a registered
+// agreement.
+func RegisterAgreements(templates []AgreementTemplate) {
+	for _, template := range templates {
+		if _, ok := agreements[template.Name]; !ok {
+			agreements[template.Name] = map[int]AgreementTemplate{}
+		}
+		agreements[template.Name][template.Version] = template
+	}
+}
+
+// FindAgreement returns a version of an agreement from templates, or its
+// latest version when version is 0.
+func FindAgreement(templates []AgreementTemplate, name string, version int) (AgreementTemplate, bool) {
+	var found AgreementTemplate
+	for _, template := range templates {
+		if template.Name != name {
+			continue
+		}
+		if template.Version == version || (version == 0 && template.Version > found.Version) {
+			found = template
+		}
+	}
+	return found, found.Version > 0
+}
+
+func latestAgreement(name string) (AgreementTemplate, bool) {
+	var latest AgreementTemplate
+	for _, template := range agreements[name] {
+		if template.Version > latest.Version {
+			latest = template
+		}
+	}
+	return latest, latest.Version > 0
+}
+
+// validateAgreement checks the agreement settings of a step.
+func validateAgreement(step StepDefinition) error {
+	if step.Agreement == nil {
+		return nil
+	}
+	if step.Evaluate != nil || step.Review != nil || step.Upload != nil || len(step.Documents) > 0 {
+		return errors.New("agreement steps take no evaluate, review, upload or documents")
+	}
+	if _, ok := latestAgreement(step.Agreement.Name); !ok {
+		return fmt.Errorf("unknown agreement %q", step.Agreement.Name)
+	}
+	return nil
+}
+
+// openAgreement returns the agreement presented in a step that is not
+// accepted yet, if any.
+func openAgreement(workflowState *WorkflowState, action string) *AgreementRecord {
+	if n := len(workflowState.Agreements); n > 0 {
+		record := &workflowState.Agreements[n-1]
+		if record.Action == action && record.Accepted == nil {
+			return record
+		}
+	}
+	return nil
+}
+
+// checkAcceptance makes sure a submission accepts the presented version of
+// an agreement.
+func checkAcceptance(record *AgreementRecord, data Mystruct) error {
+	if record == nil {
+		return errors.New("no agreement presented")
+	}
+	fields, _ := data.Payload.(map[string]interface{})
+	if accepted, _ := fields["accepted"].(bool); !accepted {
+		return errors.New("agreement not accepted")
+	}
+	if version := fmt.Sprint(fields["agreement_version"]); version != strconv.Itoa(record.Version) {
+		return fmt.Errorf("acceptance of %q version %s while version %d is presented", record.Name, version, record.Version)
+	}
+	if data.Client == nil || data.Client.Time.IsZero() {
+		return errors.New("acceptance without client details")
+	}
+	return nil
+}
+
+// presentAgreement records the version of the agreement presented in step i.
+func (r *stepRunner) presentAgreement(i int) error {
+	step := r.steps[i]
+	var template AgreementTemplate
+	err := workflow.ExecuteActivity(withActivityProfile(r.ctx, "present-agreement"), presentAgreementActivity, step.Agreement.Name).Get(r.ctx, &template)
+	if err != nil {
+		workflow.GetLogger(r.ctx).Error("Present agreement failed.", zap.String("action", step.Action), zap.Error(err))
+		return err
+	}
+	r.state.Agreements = append(r.state.Agreements, AgreementRecord{
+		Action:    step.Action,
+		Name:      template.Name,
+		Version:   template.Version,
+		Title:     template.Title,
+		Hash:      template.Hash(),
+		Presented: workflow.Now(r.ctx),
+	})
+	return nil
+}
+
+// signAgreement records the acceptance submitted for step i and stores the
+// signed record.
+func (r *stepRunner) signAgreement(i int, data Mystruct) error {
+	step := r.steps[i]
+	record := openAgreement(r.state, step.Action)
+	info := workflow.GetInfo(r.ctx)
+	signed := SignedAgreement{
+		ApplicantID: r.applicantID,
+		WorkflowID:  info.WorkflowExecution.ID,
+		RunID:       info.WorkflowExecution.RunID,
+		Action:      step.Action,
+		Name:        record.Name,
+		Version:     record.Version,
+		Title:       record.Title,
+		Hash:        record.Hash,
+		Accepted:    data.Client.Time,
+		IP:          data.Client.IP,
+		UserAgent:   data.Client.UserAgent,
+	}
+
+	var stored AgreementRecord
+	err := workflow.ExecuteActivity(withActivityProfile(r.ctx, "sign-agreement"), signAgreementActivity, signed).Get(r.ctx, &stored)
+	if err != nil {
+		workflow.GetLogger(r.ctx).Error("Sign agreement failed.", zap.String("action", step.Action), zap.Error(err))
+		return err
+	}
+	accepted := data.Client.Time
+	record.Accepted = &accepted
+	record.IP = data.Client.IP
+	record.UserAgent = data.Client.UserAgent
+	record.RecordKey = stored.RecordKey
+	record.RecordHash = stored.RecordHash
+	return nil
+}
+
+// presentAgreementActivity returns the latest version of an agreement. It
+// runs as an activity so the version presented is kept in the workflow
+// history when newer versions are deployed.
+func presentAgreementActivity(ctx context.Context, name string) (AgreementTemplate, error) {
+	template, ok := latestAgreement(name)
+	if !ok {
+		return template, fmt.Errorf("unknown agreement %q", name)
+	}
+	activity.GetLogger(ctx).Info("Presenting agreement", zap.String("agreement", name), zap.Int("version", template.Version))
+	return template, nil
+}
+
+// signAgreementActivity stores the signed record of an accepted agreement
+// under a key of its own and returns the key and the record's SHA-256. The
+// record is never overwritten: a retry finding the same record succeeds, a
+// different one fails.
+func signAgreementActivity(ctx context.Context, signed SignedAgreement) (AgreementRecord, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Sign agreement activity started", zap.String("agreement", signed.Name), zap.Int("version", signed.Version))
+
+	template, ok := agreements[signed.Name][signed.Version]
+	if !ok {
+		return AgreementRecord{}, fmt.Errorf("agreement %q version %d is not registered", signed.Name, signed.Version)
+	}
+	signed.Text = template.Text
+
+	content, err := json.MarshalIndent(signed, "", "  ")
+	if err != nil {
+		return AgreementRecord{}, err
+	}
+	sum := sha256.Sum256(content)
+	record := AgreementRecord{
+		RecordKey:  fmt.Sprintf("agreements/%s/%s/%s.v%d.%d.json", signed.WorkflowID, signed.Action, signed.Name, signed.Version, signed.Accepted.UnixNano()),
+		RecordHash: hex.EncodeToString(sum[:]),
+	}
+
+	if objectStore == nil {
+		return AgreementRecord{}, errNoObjectStore
+	}
+	existing, err := objectStore.Open(ctx, record.RecordKey)
+	if err == nil {
+		defer existing.Close()
+		stored, err := ioutil.ReadAll(existing)
+		if err != nil {
+			return AgreementRecord{}, err
+		}
+		if !bytes.Equal(stored, content) {
+			return AgreementRecord{}, fmt.Errorf("a different signed record is stored under %q", record.RecordKey)
+		}
+		logger.Info("Signed record already stored", zap.String("key", record.RecordKey))
+		return record, nil
+	}
+	if !errors.Is(err, objectstore.ErrNotFound) {
+		return AgreementRecord{}, err
+	}
+
+	if err := objectStore.Put(ctx, record.RecordKey, bytes.NewReader(content)); err != nil {
+		logger.Error("Store signed record failed.", zap.Error(err))
+		return AgreementRecord{}, err
+	}
+	logger.Info("Sign agreement activity ended", zap.String("key", record.RecordKey), zap.String("hash", record.RecordHash))
+	return record, nil
+}
@@ -0,0 +1,185 @@
+package workflows
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/adapters/objectstore"
+	"github.com/BhanuChandraAraveti/cadence-example/app/config"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/cadence/testsuite"
+)
+
+func writeAgreements(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+// withAgreements registers templates for a test and returns a function
+// restoring the previous ones.
+func withAgreements(templates ...AgreementTemplate) func() {
+	previous := agreements
+	agreements = map[string]map[int]AgreementTemplate{}
+	RegisterAgreements(templates)
+	return func() { agreements = previous }
+}
+
+func TestLoadAgreements(t *testing.T) {
+	dir := writeAgreements(t, map[string]string{
+		"terms.v2.yml":   "name: terms\nversion: 2\ntitle: Terms\ntext: Second terms.\n",
+		"terms.v1.json":  `{"name": "terms", "version": 1, "text": "First terms."}`,
+		"conduct.v1.yml": "name: conduct\nversion: 1\ntext: Be kind.\n",
+		"README.md":      "not an agreement",
+	})
+
+	templates, err := LoadAgreements(dir)
+
+	require.NoError(t, err)
+	require.Equal(t, []AgreementTemplate{
+		{Name: "conduct", Version: 1, Text: "Be kind."},
+		{Name: "terms", Version: 1, Text: "First terms."},
+		{Name: "terms", Version: 2, Title: "Terms", Text: "Second terms."},
+	}, templates)
+
+	latest, ok := FindAgreement(templates, "terms", 0)
+	require.True(t, ok)
+	require.Equal(t, 2, latest.Version)
+	first, ok := FindAgreement(templates, "terms", 1)
+	require.True(t, ok)
+	require.Equal(t, "First terms.", first.Text)
+	_, ok = FindAgreement(templates, "terms", 3)
+	require.False(t, ok)
+}
+
+func TestLoadAgreementsRejectsInvalidTemplates(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{"no text", map[string]string{"terms.yml": "name: terms\nversion: 1\n"}, "terms.yml: agreement without name or text"},
+		{"no version", map[string]string{"terms.yml": "name: terms\ntext: Terms.\n"}, `terms.yml: agreement "terms" needs a version of at least 1`},
+		{"duplicate version", map[string]string{
+			"a.yml": "name: terms\nversion: 1\ntext: Terms.\n",
+			"b.yml": "name: terms\nversion: 1\ntext: Other terms.\n",
+		}, `b.yml: agreement "terms" version 1 defined twice`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := LoadAgreements(writeAgreements(t, test.files))
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestLoadShippedAgreements(t *testing.T) {
+	templates, err := LoadAgreements("../../resources/agreements")
+
+	require.NoError(t, err)
+	latest, ok := FindAgreement(templates, "teacher-agreement", 0)
+	require.True(t, ok)
+	require.Equal(t, 2, latest.Version)
+}
+
+func TestCheckAcceptance(t *testing.T) {
+	record := &AgreementRecord{Action: "agreement", Name: "terms", Version: 2}
+	client := &ClientInfo{IP: "10.0.0.1", Time: time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)}
+	tests := []struct {
+		name   string
+		record *AgreementRecord
+		data   Mystruct
+		err    string
+	}{
+		{"accepted", record, Mystruct{Payload: map[string]interface{}{"accepted": true, "agreement_version": 2.0}, Client: client}, ""},
+		{"nothing presented", nil, Mystruct{Payload: map[string]interface{}{"accepted": true, "agreement_version": 2.0}, Client: client}, "no agreement presented"},
+		{"declined", record, Mystruct{Payload: map[string]interface{}{"accepted": false, "agreement_version": 2.0}, Client: client}, "agreement not accepted"},
+		{"older version", record, Mystruct{Payload: map[string]interface{}{"accepted": true, "agreement_version": 1.0}, Client: client}, `acceptance of "terms" version 1 while version 2 is presented`},
+		{"no client", record, Mystruct{Payload: map[string]interface{}{"accepted": true, "agreement_version": 2.0}}, "acceptance without client details"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkAcceptance(test.record, test.data)
+			if test.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestSignAgreementNeverOverwritesRecords(t *testing.T) {
+	defer withAgreements(AgreementTemplate{Name: "terms", Version: 1, Text: "Terms."})()
+	store := objectstore.NewLocal(config.ObjectStoreConfig{Dir: t.TempDir(), Secret: "test"})
+	withUploadAdapters(t, store, HeaderProbe{})
+	signed := SignedAgreement{WorkflowID: "workflow-1", Action: "agreement", Name: "terms", Version: 1, Accepted: time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)}
+	sign := func(signed SignedAgreement) (AgreementRecord, error) {
+		var suite testsuite.WorkflowTestSuite
+		value, err := suite.NewTestActivityEnvironment().ExecuteActivity(signAgreementActivity, signed)
+		if err != nil {
+			return AgreementRecord{}, err
+		}
+		var record AgreementRecord
+		require.NoError(t, value.Get(&record))
+		return record, nil
+	}
+
+	record, err := sign(signed)
+	require.NoError(t, err)
+	retried, err := sign(signed)
+	require.NoError(t, err)
+	require.Equal(t, record, retried, "a retry finds the same record")
+
+	signed.IP = "10.0.0.2"
+	_, err = sign(signed)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "a different signed record is stored")
+}
+
+func (s *StepsTestSuite) Test_AgreementStoresSignedRecord() {
+	defer withAgreements(
+		AgreementTemplate{Name: "terms", Version: 1, Text: "First terms."},
+		AgreementTemplate{Name: "terms", Version: 2, Title: "Terms", Text: "Second terms."},
+	)()
+	store := objectstore.NewLocal(config.ObjectStoreConfig{Dir: s.T().TempDir(), Secret: "test"})
+	withUploadAdapters(s.T(), store, HeaderProbe{})
+	accepted := time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)
+	client := &ClientInfo{IP: "10.0.0.1", UserAgent: "test", Time: accepted}
+	s.signal(SignalName, Mystruct{ApplicantId: "applicant-1", Action: "agreement", Payload: map[string]interface{}{"accepted": true, "agreement_version": 1}, Client: client})
+	s.signal(SignalName, Mystruct{ApplicantId: "applicant-1", Action: "agreement", Payload: map[string]interface{}{"accepted": true, "agreement_version": 2}, Client: client})
+
+	result, state := s.run(StepDefinition{Action: "agreement", Agreement: &AgreementDefinition{Name: "terms"}})
+
+	s.Equal("Step runner completed", result)
+	s.Require().Len(state.Rejections, 1)
+	s.Equal(`acceptance of "terms" version 1 while version 2 is presented`, state.Rejections[0].Reason)
+	s.Require().Len(state.Agreements, 1)
+	record := state.Agreements[0]
+	s.Equal(2, record.Version)
+	s.Equal(AgreementTemplate{Text: "Second terms."}.Hash(), record.Hash)
+	s.Require().NotNil(record.Accepted)
+	s.True(accepted.Equal(*record.Accepted))
+	s.Equal("10.0.0.1", record.IP)
+
+	file, err := store.Open(context.Background(), record.RecordKey)
+	s.Require().NoError(err)
+	defer file.Close()
+	content, err := ioutil.ReadAll(file)
+	s.Require().NoError(err)
+	sum := sha256.Sum256(content)
+	s.Equal(hex.EncodeToString(sum[:]), record.RecordHash)
+	var stored SignedAgreement
+	s.Require().NoError(json.Unmarshal(content, &stored))
+	s.Equal("Second terms.", stored.Text)
+	s.Equal("applicant-1", stored.ApplicantID)
+}
@@ -0,0 +1,377 @@
+package workflows
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/cadence/workflow"
+
+	// Time zones are embedded for workers without a zoneinfo database.
+	// time.LoadLocation prefers the system database where there is one, so
+	// conversions made in workflows are recorded in side effects.
+	_ "time/tzdata"
+)
+
+// weekdays are the day names used in availability slots and windows, in the
+// order of a week starting on Monday.
+var weekdays = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+
+const (
+	minutesPerDay  = 24 * 60
+	minutesPerWeek = 7 * minutesPerDay
+)
+
+// AvailabilityDefinition makes a step collect a weekly availability grid.
+// Slots are submitted in the applicant's timezone and must fall within the
+// allowed Windows, which are given in Timezone (UTC when empty), start and end
+// on multiples of SlotMinutes and add up to at least MinHours a week.
+type AvailabilityDefinition struct {
+	Timezone    string               `json:"timezone,omitempty"`
+	Windows     []AvailabilityWindow `json:"windows,omitempty"`
+	SlotMinutes int                  `json:"slot_minutes,omitempty"`
+	MinHours    float64              `json:"min_hours,omitempty"`
+}
+
+// AvailabilityWindow is a time of day, "HH:MM" to "HH:MM", on the given days,
+// or on every day when Days is empty. An End of "24:00" is the end of the day.
+type AvailabilityWindow struct {
+	Days  []string `json:"days,omitempty"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+}
+
+// Slot is a weekly time slot, "HH:MM" to "HH:MM" on a day.
+type Slot struct {
+	Day   string `json:"day"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// Schedule is a normalized weekly availability: the slots in the applicant's
+// timezone and in UTC, sorted and with overlapping slots merged.
+type Schedule struct {
+	Action   string  `json:"action"`
+	Timezone string  `json:"timezone"`
+	Slots    []Slot  `json:"slots"`
+	UTC      []Slot  `json:"utc"`
+	Hours    float64 `json:"hours"`
+}
+
+// setSchedule records the schedule of a step, replacing one submitted before
+// a go-back.
+func setSchedule(workflowState *WorkflowState, schedule Schedule) {
+	for i := range workflowState.Schedules {
+		if workflowState.Schedules[i].Action == schedule.Action {
+			workflowState.Schedules[i] = schedule
+			return
+		}
+	}
+	workflowState.Schedules = append(workflowState.Schedules, schedule)
+}
+
+// interval is a span of minutes of the week, counted from Monday 00:00.
+type interval struct {
+	start int
+	end   int
+}
+
+// validateAvailability checks the availability settings of a step.
+func validateAvailability(step StepDefinition) error {
+	def := step.Availability
+	if def == nil {
+		return nil
+	}
+	if step.Evaluate != nil || step.Review != nil || step.Upload != nil || len(step.Documents) > 0 || step.Agreement != nil {
+		return errors.New("availability steps take no evaluate, review, upload, documents or agreement")
+	}
+	if _, err := time.LoadLocation(def.Timezone); err != nil || def.Timezone == "Local" {
+		return fmt.Errorf("invalid timezone %q", def.Timezone)
+	}
+	if def.SlotMinutes < 0 || (def.SlotMinutes > 0 && minutesPerDay%def.SlotMinutes != 0) {
+		return fmt.Errorf("slotMinutes %d does not divide a day", def.SlotMinutes)
+	}
+	if def.MinHours < 0 {
+		return errors.New("negative minHours")
+	}
+	for _, window := range def.Windows {
+		if _, err := dayIntervals(window.Days, window.Start, window.End); err != nil {
+			return fmt.Errorf("window: %w", err)
+		}
+	}
+	return nil
+}
+
+// normalizedAvailability is the outcome of normalizing a submission, as
+// recorded in the history.
+type normalizedAvailability struct {
+	Schedule Schedule `json:"schedule"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// normalizeAvailability normalizes an availability submission for step. The
+// conversion depends on the time zone database of the worker, so it runs in
+// a side effect and replays use the recorded schedule.
+func (r *stepRunner) normalizeAvailability(step StepDefinition, payload interface{}) (Schedule, error) {
+	now := workflow.Now(r.ctx)
+	var result normalizedAvailability
+	err := workflow.SideEffect(r.ctx, func(ctx workflow.Context) interface{} {
+		schedule, err := NormalizeAvailability(*step.Availability, payload, now)
+		if err != nil {
+			return normalizedAvailability{Error: err.Error()}
+		}
+		return normalizedAvailability{Schedule: schedule}
+	}).Get(&result)
+	if err != nil {
+		return Schedule{}, err
+	}
+	if result.Error != "" {
+		return Schedule{}, errors.New(result.Error)
+	}
+	result.Schedule.Action = step.Action
+	return result.Schedule, nil
+}
+
+// NormalizeAvailability validates an availability payload, an object with
+// the applicant's "timezone" and the "slots" array, against def and returns
+// the normalized schedule. Slots are converted between timezones in the week
+// of now, so daylight saving time is applied as it is that week. The timezone
+// must be named: "Local" depends on the machine.
+func NormalizeAvailability(def AvailabilityDefinition, payload interface{}, now time.Time) (Schedule, error) {
+	fields, _ := payload.(map[string]interface{})
+	timezone, _ := fields["timezone"].(string)
+	location, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "" || timezone == "Local" {
+		return Schedule{}, fmt.Errorf("invalid timezone %q", timezone)
+	}
+	windowLocation, err := time.LoadLocation(def.Timezone)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	slots, err := toSlots(fields["slots"])
+	if err != nil {
+		return Schedule{}, err
+	}
+	var local []interval
+	for _, slot := range slots {
+		spans, err := dayIntervals([]string{slot.Day}, slot.Start, slot.End)
+		if err != nil {
+			return Schedule{}, fmt.Errorf("slot %s %s-%s: %w", slot.Day, slot.Start, slot.End, err)
+		}
+		if def.SlotMinutes > 0 && (spans[0].start%def.SlotMinutes != 0 || spans[0].end%def.SlotMinutes != 0) {
+			return Schedule{}, fmt.Errorf("slot %s %s-%s is not on the %d minute grid", slot.Day, slot.Start, slot.End, def.SlotMinutes)
+		}
+		local = append(local, spans...)
+	}
+	local = mergeIntervals(local)
+	utc := toUTC(local, location, now)
+
+	if len(def.Windows) > 0 {
+		var windows []interval
+		for _, window := range def.Windows {
+			spans, _ := dayIntervals(window.Days, window.Start, window.End)
+			windows = append(windows, spans...)
+		}
+		allowed := toUTC(mergeIntervals(windows), windowLocation, now)
+		for _, span := range utc {
+			if !covered(span, allowed) {
+				slot := fromIntervals(toLocal([]interval{span}, location, now))[0]
+				return Schedule{}, fmt.Errorf("slot %s %s-%s is outside the allowed windows", slot.Day, slot.Start, slot.End)
+			}
+		}
+	}
+
+	minutes := 0
+	for _, span := range local {
+		minutes += span.end - span.start
+	}
+	hours := float64(minutes) / 60
+	if hours < def.MinHours {
+		return Schedule{}, fmt.Errorf("%v hours a week is less than the minimum of %v", hours, def.MinHours)
+	}
+
+	return Schedule{
+		Timezone: timezone,
+		Slots:    fromIntervals(local),
+		UTC:      fromIntervals(utc),
+		Hours:    hours,
+	}, nil
+}
+
+// toSlots converts the slots array of a payload into slots.
+func toSlots(value interface{}) ([]Slot, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New("slots must be an array")
+	}
+	slots := make([]Slot, 0, len(items))
+	for _, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("slots must be objects with day, start and end")
+		}
+		slot := Slot{}
+		slot.Day, _ = fields["day"].(string)
+		slot.Start, _ = fields["start"].(string)
+		slot.End, _ = fields["end"].(string)
+		slots = append(slots, slot)
+	}
+	return slots, nil
+}
+
+// dayIntervals returns the intervals of a time of day on the given days, or
+// on every day when days is empty.
+func dayIntervals(days []string, start string, end string) ([]interval, error) {
+	from, err := parseClock(start)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseClock(end)
+	if err != nil {
+		return nil, err
+	}
+	if from >= to || from == minutesPerDay {
+		return nil, fmt.Errorf("start %s is not before end %s", start, end)
+	}
+	if len(days) == 0 {
+		days = weekdays
+	}
+	var spans []interval
+	for _, day := range days {
+		index := dayIndex(day)
+		if index < 0 {
+			return nil, fmt.Errorf("unknown day %q", day)
+		}
+		spans = append(spans, interval{index*minutesPerDay + from, index*minutesPerDay + to})
+	}
+	return spans, nil
+}
+
+// parseClock parses "HH:MM" into minutes of the day. "24:00" is allowed as
+// the end of the day.
+func parseClock(clock string) (int, error) {
+	parts := strings.Split(clock, ":")
+	if len(parts) != 2 || len(parts[1]) != 2 {
+		return 0, fmt.Errorf("invalid time %q", clock)
+	}
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", clock)
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil || hours < 0 || minutes < 0 || minutes > 59 || hours*60+minutes > minutesPerDay {
+		return 0, fmt.Errorf("invalid time %q", clock)
+	}
+	return hours*60 + minutes, nil
+}
+
+func dayIndex(day string) int {
+	for i, name := range weekdays {
+		if strings.EqualFold(day, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeIntervals sorts intervals and merges the ones that overlap or touch.
+func mergeIntervals(spans []interval) []interval {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	var merged []interval
+	for _, span := range spans {
+		if n := len(merged); n > 0 && span.start <= merged[n-1].end {
+			if span.end > merged[n-1].end {
+				merged[n-1].end = span.end
+			}
+			continue
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
+
+// toUTC converts intervals of the week in location to UTC. Intervals are
+// placed in the week of now; ones shifted across the start or end of the
+// week wrap around.
+func toUTC(spans []interval, location *time.Location, now time.Time) []interval {
+	return shiftIntervals(spans, func(minute int) int {
+		return offsetMinutes(weekStart(now, location).Add(time.Duration(minute) * time.Minute))
+	}, -1)
+}
+
+// toLocal converts UTC intervals of the week to location.
+func toLocal(spans []interval, location *time.Location, now time.Time) []interval {
+	return shiftIntervals(spans, func(minute int) int {
+		return offsetMinutes(weekStart(now, time.UTC).Add(time.Duration(minute) * time.Minute).In(location))
+	}, 1)
+}
+
+// shiftIntervals moves each interval by the UTC offset at its start, in the
+// given direction, wrapping around the week.
+func shiftIntervals(spans []interval, offset func(minute int) int, direction int) []interval {
+	var shifted []interval
+	for _, span := range spans {
+		delta := direction * offset(span.start)
+		start := ((span.start+delta)%minutesPerWeek + minutesPerWeek) % minutesPerWeek
+		end := start + span.end - span.start
+		if end > minutesPerWeek {
+			shifted = append(shifted, interval{start, minutesPerWeek}, interval{0, end - minutesPerWeek})
+			continue
+		}
+		shifted = append(shifted, interval{start, end})
+	}
+	return mergeIntervals(shifted)
+}
+
+// weekStart returns Monday 00:00 of the week of now in location.
+func weekStart(now time.Time, location *time.Location) time.Time {
+	local := now.In(location)
+	days := (int(local.Weekday()) + 6) % 7
+	return time.Date(local.Year(), local.Month(), local.Day()-days, 0, 0, 0, 0, location)
+}
+
+// offsetMinutes returns the UTC offset of t in minutes.
+func offsetMinutes(t time.Time) int {
+	_, offset := t.Zone()
+	return offset / 60
+}
+
+// covered reports whether span lies within one of the merged intervals.
+func covered(span interval, allowed []interval) bool {
+	for _, window := range allowed {
+		if span.start >= window.start && span.end <= window.end {
+			return true
+		}
+	}
+	return false
+}
+
+// fromIntervals converts intervals of the week into slots, splitting them at
+// midnight.
+func fromIntervals(spans []interval) []Slot {
+	slots := []Slot{}
+	for _, span := range spans {
+		for start := span.start; start < span.end; {
+			day := start / minutesPerDay
+			end := span.end
+			if dayEnd := (day + 1) * minutesPerDay; end > dayEnd {
+				end = dayEnd
+			}
+			slots = append(slots, Slot{
+				Day:   weekdays[day],
+				Start: formatClock(start - day*minutesPerDay),
+				End:   formatClock(end - day*minutesPerDay),
+			})
+			start = end
+		}
+	}
+	return slots
+}
+
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
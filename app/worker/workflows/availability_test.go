@@ -0,0 +1,109 @@
+package workflows
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// monday is a Monday in January, when New York is on standard time.
+var monday = time.Date(2024, time.January, 8, 12, 0, 0, 0, time.UTC)
+
+func availability(timezone string, slots ...Slot) map[string]interface{} {
+	items := []interface{}{}
+	for _, slot := range slots {
+		items = append(items, map[string]interface{}{"day": slot.Day, "start": slot.Start, "end": slot.End})
+	}
+	return map[string]interface{}{"timezone": timezone, "slots": items}
+}
+
+func TestNormalizeAvailabilityMergesAndConvertsToUTC(t *testing.T) {
+	schedule, err := NormalizeAvailability(AvailabilityDefinition{}, availability("Asia/Kolkata",
+		Slot{Day: "monday", Start: "10:00", End: "11:00"},
+		Slot{Day: "Monday", Start: "09:00", End: "10:00"},
+	), monday)
+
+	require.NoError(t, err)
+	require.Equal(t, "Asia/Kolkata", schedule.Timezone)
+	require.Equal(t, []Slot{{Day: "monday", Start: "09:00", End: "11:00"}}, schedule.Slots)
+	require.Equal(t, []Slot{{Day: "monday", Start: "03:30", End: "05:30"}}, schedule.UTC)
+	require.Equal(t, 2.0, schedule.Hours)
+}
+
+func TestNormalizeAvailabilityWrapsAroundTheWeek(t *testing.T) {
+	schedule, err := NormalizeAvailability(AvailabilityDefinition{}, availability("Asia/Kolkata",
+		Slot{Day: "monday", Start: "04:00", End: "07:00"},
+	), monday)
+
+	require.NoError(t, err)
+	require.Equal(t, []Slot{
+		{Day: "monday", Start: "00:00", End: "01:30"},
+		{Day: "sunday", Start: "22:30", End: "24:00"},
+	}, schedule.UTC)
+}
+
+func TestNormalizeAvailabilityAppliesDaylightSavingOfTheWeek(t *testing.T) {
+	payload := availability("America/New_York", Slot{Day: "tuesday", Start: "09:00", End: "10:00"})
+
+	winter, err := NormalizeAvailability(AvailabilityDefinition{}, payload, monday)
+	require.NoError(t, err)
+	summer, err := NormalizeAvailability(AvailabilityDefinition{}, payload, monday.AddDate(0, 6, 0))
+	require.NoError(t, err)
+
+	require.Equal(t, []Slot{{Day: "tuesday", Start: "14:00", End: "15:00"}}, winter.UTC)
+	require.Equal(t, []Slot{{Day: "tuesday", Start: "13:00", End: "14:00"}}, summer.UTC)
+}
+
+func TestNormalizeAvailabilityChecksTheDefinition(t *testing.T) {
+	def := AvailabilityDefinition{
+		Timezone:    "Asia/Kolkata",
+		Windows:     []AvailabilityWindow{{Days: []string{"monday"}, Start: "09:00", End: "18:00"}},
+		SlotMinutes: 30,
+		MinHours:    1,
+	}
+	tests := []struct {
+		name    string
+		payload interface{}
+		err     string
+	}{
+		{"in window", availability("UTC", Slot{Day: "monday", Start: "04:00", End: "05:00"}), ""},
+		{"outside windows", availability("UTC", Slot{Day: "monday", Start: "13:00", End: "14:00"}), "slot monday 13:00-14:00 is outside the allowed windows"},
+		{"off grid", availability("UTC", Slot{Day: "monday", Start: "04:15", End: "05:15"}), "slot monday 04:15-05:15 is not on the 30 minute grid"},
+		{"too few hours", availability("UTC", Slot{Day: "monday", Start: "04:00", End: "04:30"}), "0.5 hours a week is less than the minimum of 1"},
+		{"unknown day", availability("UTC", Slot{Day: "someday", Start: "04:00", End: "05:00"}), `slot someday 04:00-05:00: unknown day "someday"`},
+		{"no slots", map[string]interface{}{"timezone": "UTC"}, "slots must be an array"},
+		{"no timezone", availability("", Slot{Day: "monday", Start: "04:00", End: "05:00"}), `invalid timezone ""`},
+		{"local timezone", availability("Local", Slot{Day: "monday", Start: "04:00", End: "05:00"}), `invalid timezone "Local"`},
+		{"unknown timezone", availability("Mars/Olympus", Slot{Day: "monday", Start: "04:00", End: "05:00"}), `invalid timezone "Mars/Olympus"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NormalizeAvailability(def, test.payload, monday)
+			if test.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestValidateAvailabilityRejectsLocalTimezone(t *testing.T) {
+	err := validateAvailability(StepDefinition{Action: "availability", Availability: &AvailabilityDefinition{Timezone: "Local"}})
+	require.EqualError(t, err, `invalid timezone "Local"`)
+}
+
+func (s *StepsTestSuite) Test_AvailabilityRecordsSchedule() {
+	s.submit("availability", availability("Local", Slot{Day: "monday", Start: "09:00", End: "10:00"}))
+	s.submit("availability", availability("Asia/Kolkata", Slot{Day: "monday", Start: "09:00", End: "10:00"}))
+
+	result, state := s.run(StepDefinition{Action: "availability", Availability: &AvailabilityDefinition{}})
+
+	s.Equal("Step runner completed", result)
+	s.Require().Len(state.Rejections, 1)
+	s.Equal(`invalid timezone "Local"`, state.Rejections[0].Reason)
+	s.Require().Len(state.Schedules, 1)
+	s.Equal("availability", state.Schedules[0].Action)
+	s.Equal([]Slot{{Day: "monday", Start: "03:30", End: "04:30"}}, state.Schedules[0].UTC)
+}
@@ -0,0 +1,88 @@
+package workflows
+
+import (
+	"fmt"
+)
+
+// StatusSkipped marks a step whose conditions did not hold.
+const StatusSkipped = "SKIPPED"
+
+// Condition is a predicate over a field of the payload submitted for an
+// earlier step. It holds when the field equals Equals or one of In; for array
+// fields it holds when any element does.
+type Condition struct {
+	Step   string   `json:"step"`
+	Field  string   `json:"field"`
+	Equals string   `json:"equals,omitempty"`
+	In     []string `json:"in,omitempty"`
+}
+
+// validateConditions makes sure the conditions of every step only reference
+// steps that come before it.
+func validateConditions(steps []StepDefinition) error {
+	earlier := map[string]bool{}
+	for _, step := range steps {
+		for _, cond := range step.When {
+			if cond.Field == "" {
+				return fmt.Errorf("step %q: condition without field", step.Action)
+			}
+			if cond.Equals == "" && len(cond.In) == 0 {
+				return fmt.Errorf("step %q: condition on %q needs equals or in", step.Action, cond.Field)
+			}
+			if !earlier[cond.Step] {
+				return fmt.Errorf("step %q: condition references %q which is not an earlier step", step.Action, cond.Step)
+			}
+		}
+		earlier[step.Action] = true
+	}
+	return nil
+}
+
+// shouldRun reports whether all conditions of a step hold for the payloads
+// submitted so far, keyed by step action.
+func shouldRun(step StepDefinition, payloads map[string]interface{}) bool {
+	for _, cond := range step.When {
+		if !cond.holds(payloads) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Condition) holds(payloads map[string]interface{}) bool {
+	fields, ok := payloads[c.Step].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	switch value := fields[c.Field].(type) {
+	case nil:
+		return false
+	case []interface{}:
+		for _, item := range value {
+			if c.matches(item) {
+				return true
+			}
+		}
+		return false
+	default:
+		return c.matches(value)
+	}
+}
+
+func (c Condition) matches(value interface{}) bool {
+	s := fmt.Sprint(value)
+	if c.Equals != "" && s == c.Equals {
+		return true
+	}
+	for _, allowed := range c.In {
+		if s == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// skip marks the current step as skipped and moves on to the next one.
+func skip(workflowState *WorkflowState) {
+	moveOn(workflowState, StatusSkipped)
+}
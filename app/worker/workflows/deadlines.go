@@ -0,0 +1,96 @@
+package workflows
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+)
+
+// StatusExpired marks a step that was not submitted before its ExpireAfter
+// deadline.
+const StatusExpired = "EXPIRED"
+
+// errJourneyExpired is returned by runSteps when a step expired and closed the
+// journey.
+var errJourneyExpired = errors.New("journey expired")
+
+// validateDeadlines checks the deadline settings of a step.
+func validateDeadlines(step StepDefinition) error {
+	if step.RemindAfter < 0 || step.ExpireAfter < 0 {
+		return errors.New("negative deadline")
+	}
+	if step.RemindAfter > 0 && step.ExpireAfter > 0 && step.RemindAfter >= step.ExpireAfter {
+		return errors.New("remindAfter must come before expireAfter")
+	}
+	if step.OnExpire != "" && step.OnExpire != "close" && step.OnExpire != "dormant" {
+		return fmt.Errorf("unknown onExpire %q", step.OnExpire)
+	}
+	return nil
+}
+
+// addDeadlines adds the reminder and expiry timers of a step to selector. The
+// timers are cancelled with ctx.
+func addDeadlines(ctx workflow.Context, selector workflow.Selector, step StepDefinition, event *string) {
+	if step.RemindAfter > 0 {
+		selector.AddFuture(workflow.NewTimer(ctx, step.RemindAfter), func(f workflow.Future) {
+			if f.Get(ctx, nil) == nil {
+				*event = eventRemind
+			}
+		})
+	}
+	if step.ExpireAfter > 0 {
+		selector.AddFuture(workflow.NewTimer(ctx, step.ExpireAfter), func(f workflow.Future) {
+			if f.Get(ctx, nil) == nil {
+				*event = eventExpire
+			}
+		})
+	}
+}
+
+// remind reminds the applicant of a step.
+func (r *stepRunner) remind(step StepDefinition) {
+	r.sendReminder(Reminder{
+		ApplicantID: r.applicantID,
+		WorkflowID:  workflow.GetInfo(r.ctx).WorkflowExecution.ID,
+		Action:      step.Action,
+		Time:        workflow.Now(r.ctx),
+	})
+}
+
+// sendReminder runs the reminder activity and records the reminder. A failed
+// reminder is logged and does not stop the journey.
+func (r *stepRunner) sendReminder(reminder Reminder) {
+	var activityResult string
+	err := workflow.ExecuteActivity(withActivityProfile(r.ctx, "send-reminder"), sendReminderActivity, reminder).Get(r.ctx, &activityResult)
+	if err != nil {
+		workflow.GetLogger(r.ctx).Error("Reminder Activity failed.", zap.String("action", reminder.Action), zap.Error(err))
+		return
+	}
+	r.state.Reminders = append(r.state.Reminders, reminder)
+}
+
+// expire marks step i as expired. It reports whether the journey stays open as
+// dormant; otherwise the journey is closed.
+func (r *stepRunner) expire(i int) bool {
+	step := r.steps[i]
+	workflow.GetLogger(r.ctx).Info("Step expired.", zap.String("action", step.Action), zap.String("onExpire", step.OnExpire))
+
+	r.state.Steps[i].Status = StatusExpired
+	r.state.Current = r.state.Steps[i]
+	if step.OnExpire != "dormant" {
+		return false
+	}
+	r.state.Dormant = true
+	return true
+}
+
+// reactivate brings a dormant journey back to step i.
+func (r *stepRunner) reactivate(i int) {
+	workflow.GetLogger(r.ctx).Info("Journey reactivated.", zap.String("action", r.steps[i].Action))
+
+	r.state.Dormant = false
+	r.state.Steps[i].Status = StatusInProgress
+	r.state.Current = r.state.Steps[i]
+}
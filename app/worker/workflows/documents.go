@@ -0,0 +1,268 @@
+package workflows
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+)
+
+// Signals of a documents step: the applicant uploaded a document, and a
+// reviewer verified or rejected one.
+const (
+	DocumentUploadedSignalName = "document-uploaded"
+	VerifyDocumentSignalName   = "verify-document"
+	RejectDocumentSignalName   = "reject-document"
+)
+
+// Document statuses used in DocumentStatus.Status.
+const (
+	DocumentPending  = "PENDING"
+	DocumentUploaded = "UPLOADED"
+	DocumentVerified = "VERIFIED"
+	DocumentRejected = "REJECTED"
+)
+
+// DocumentDefinition is a document the applicant submits in a documents
+// step, e.g. an ID or a degree certificate.
+type DocumentDefinition struct {
+	Type     string `json:"type"`
+	Name     string `json:"name,omitempty"`
+	Required bool   `json:"required,omitempty"`
+}
+
+// DocumentStatus tracks a document of a documents step. A rejected document
+// can be uploaded again.
+type DocumentStatus struct {
+	Action   string    `json:"action"`
+	Type     string    `json:"type"`
+	Name     string    `json:"name,omitempty"`
+	Required bool      `json:"required,omitempty"`
+	Status   string    `json:"status"`
+	Key      string    `json:"key,omitempty"`
+	Uploads  int       `json:"uploads,omitempty"`
+	Reviewer string    `json:"reviewer,omitempty"`
+	Notes    string    `json:"notes,omitempty"`
+	Updated  time.Time `json:"updated"`
+}
+
+// DocumentSignal is the payload of the document-uploaded, verify-document and
+// reject-document signals. Key is set by uploads, Reviewer by verifications
+// and rejections.
+type DocumentSignal struct {
+	WorkflowId string `json:"workflowId"`
+	RunId      string `json:"runId"`
+	Action     string `json:"action"`
+	Type       string `json:"type"`
+	Key        string `json:"key,omitempty"`
+	Reviewer   string `json:"reviewer,omitempty"`
+	Notes      string `json:"notes,omitempty"`
+}
+
+// DocumentPrefix is the prefix of the keys applicants upload a document of a
+// documents step to.
+func DocumentPrefix(workflowID string, action string, documentType string) string {
+	return UploadPrefix(workflowID, action) + documentType + "/"
+}
+
+// validateDocuments checks the documents of a step. Documents steps are
+// completed by document signals, so they take no payload.
+func validateDocuments(step StepDefinition) error {
+	if len(step.Documents) == 0 {
+		return nil
+	}
+	if len(step.Payload) > 0 || step.Evaluate != nil || step.Review != nil || step.Upload != nil || step.Signal != "" {
+		return errors.New("documents steps take no payload, evaluate, review, upload or signal")
+	}
+	seen := map[string]bool{}
+	required := false
+	for _, document := range step.Documents {
+		if document.Type == "" || strings.Contains(document.Type, "/") {
+			return fmt.Errorf("invalid document type %q", document.Type)
+		}
+		if seen[document.Type] {
+			return fmt.Errorf("document %q defined twice", document.Type)
+		}
+		seen[document.Type] = true
+		required = required || document.Required
+	}
+	if !required {
+		return errors.New("no required documents")
+	}
+	return nil
+}
+
+// openDocuments returns the documents of the documents step the journey is
+// waiting on, if any.
+func openDocuments(workflowState *WorkflowState) []DocumentStatus {
+	action := workflowState.Current.Action
+	if workflowState.Current.Status != StatusInProgress {
+		return nil
+	}
+	var documents []DocumentStatus
+	for _, document := range workflowState.Documents {
+		if document.Action == action {
+			documents = append(documents, document)
+		}
+	}
+	return documents
+}
+
+// waitForDocuments tracks the documents of step i until every required one
+// is verified. Each document is uploaded and verified or rejected on its own;
+// rejected documents are uploaded again. Signals that do not fit a document
+// are recorded as rejections. It returns the keys of the verified documents
+// by type as the step payload, and -1, or nil and the index of the step to
+// resume from when a valid go-back signal rewinds the state.
+func (r *stepRunner) waitForDocuments(i int) (map[string]interface{}, int) {
+	logger := workflow.GetLogger(r.ctx)
+	step := r.steps[i]
+	workflowID := workflow.GetInfo(r.ctx).WorkflowExecution.ID
+
+	// Documents of an earlier visit to the step, e.g. before a go-back, keep
+	// their status, so verified documents are not uploaded again.
+	earlier := map[string]DocumentStatus{}
+	kept := r.state.Documents[:0]
+	for _, document := range r.state.Documents {
+		if document.Action == step.Action {
+			earlier[document.Type] = document
+		} else {
+			kept = append(kept, document)
+		}
+	}
+	r.state.Documents = kept
+	first := len(r.state.Documents)
+	now := workflow.Now(r.ctx)
+	for _, definition := range step.Documents {
+		document, ok := earlier[definition.Type]
+		if !ok {
+			document = DocumentStatus{Action: step.Action, Type: definition.Type, Status: DocumentPending, Updated: now}
+		}
+		document.Name = definition.Name
+		document.Required = definition.Required
+		r.state.Documents = append(r.state.Documents, document)
+	}
+	documents := r.state.Documents[first:]
+
+	var data DocumentSignal
+	signalName := ""
+	selector := workflow.NewSelector(r.ctx)
+	for _, name := range []string{DocumentUploadedSignalName, VerifyDocumentSignalName, RejectDocumentSignalName} {
+		name := name
+		selector.AddReceive(workflow.GetSignalChannel(r.ctx, name), func(c workflow.Channel, more bool) {
+			// Decode into an empty signal so fields left out of a signal do
+			// not carry over from the previous one.
+			data = DocumentSignal{}
+			c.Receive(r.ctx, &data)
+			signalName = name
+			logger.Info("Received the signal!", zap.String("signal", name), zap.String("document", data.Type))
+		})
+	}
+	var back Mystruct
+	if workflow.GetVersion(r.ctx, "documents-go-back", workflow.DefaultVersion, 1) == 1 {
+		selector.AddReceive(workflow.GetSignalChannel(r.ctx, GoBackSignalName), func(c workflow.Channel, more bool) {
+			c.Receive(r.ctx, &back)
+			signalName = GoBackSignalName
+			logger.Info("Received the signal!", zap.String("signal", GoBackSignalName), zap.String("action", back.Action))
+		})
+	}
+
+	for !documentsVerified(documents) {
+		logger.Info("Waiting for documents", zap.String("action", step.Action))
+		selector.Select(r.ctx)
+
+		if signalName == GoBackSignalName {
+			target, err := rewindTarget(r.state, i, back.Action)
+			if err != nil {
+				r.reject(back.Action, err)
+				continue
+			}
+			rewind(r.ctx, r.state, target)
+			return nil, target
+		}
+
+		document := findDocument(documents, data.Type)
+		err := checkDocumentSignal(document, step.Action, signalName, data, DocumentPrefix(workflowID, step.Action, data.Type))
+		if err != nil {
+			r.reject(step.Action, err)
+			continue
+		}
+
+		document.Updated = workflow.Now(r.ctx)
+		switch signalName {
+		case DocumentUploadedSignalName:
+			document.Status = DocumentUploaded
+			document.Key = data.Key
+			document.Uploads++
+			document.Reviewer = ""
+			document.Notes = ""
+		case VerifyDocumentSignalName:
+			document.Status = DocumentVerified
+			document.Reviewer = data.Reviewer
+			document.Notes = data.Notes
+		case RejectDocumentSignalName:
+			document.Status = DocumentRejected
+			document.Reviewer = data.Reviewer
+			document.Notes = data.Notes
+		}
+	}
+
+	payload := map[string]interface{}{}
+	for _, document := range documents {
+		if document.Status == DocumentVerified {
+			payload[document.Type] = document.Key
+		}
+	}
+	return payload, -1
+}
+
+// documentsVerified reports whether every required document is verified.
+func documentsVerified(documents []DocumentStatus) bool {
+	for _, document := range documents {
+		if document.Required && document.Status != DocumentVerified {
+			return false
+		}
+	}
+	return true
+}
+
+func findDocument(documents []DocumentStatus, documentType string) *DocumentStatus {
+	for i := range documents {
+		if documents[i].Type == documentType {
+			return &documents[i]
+		}
+	}
+	return nil
+}
+
+// checkDocumentSignal makes sure a document signal is for a document of the
+// step in a status it applies to: uploads for documents that are not yet
+// verified, to a key issued for them, and decisions by a reviewer for
+// uploaded documents.
+func checkDocumentSignal(document *DocumentStatus, action string, signalName string, data DocumentSignal, prefix string) error {
+	if data.Action != action {
+		return fmt.Errorf("document for %q while waiting on %q", data.Action, action)
+	}
+	if document == nil {
+		return fmt.Errorf("unknown document %q", data.Type)
+	}
+	if signalName == DocumentUploadedSignalName {
+		if document.Status == DocumentVerified {
+			return fmt.Errorf("document %q already verified", data.Type)
+		}
+		if !strings.HasPrefix(data.Key, prefix) {
+			return fmt.Errorf("document key %q not issued for %q", data.Key, data.Type)
+		}
+		return nil
+	}
+	if data.Reviewer == "" {
+		return errors.New("missing reviewer")
+	}
+	if document.Status != DocumentUploaded {
+		return fmt.Errorf("document %q is %s", data.Type, document.Status)
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package workflows
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+)
+
+// JourneyDefinition is a named, versioned list of steps loaded from the
+// journeys directory. Journeys with stages group their steps under parent
+// steps the way the teacher journey groups lead and application.
+type JourneyDefinition struct {
+	Name    string            `json:"name"`
+	Version int               `json:"version"`
+	Timeout time.Duration     `json:"timeout,omitempty"`
+	Steps   []StepDefinition  `json:"steps,omitempty"`
+	Stages  []StageDefinition `json:"stages,omitempty"`
+}
+
+// StageDefinition is a parent step of a staged journey.
+type StageDefinition struct {
+	Action string           `json:"action"`
+	Steps  []StepDefinition `json:"steps"`
+}
+
+// journeys holds the definitions registered at worker startup, by name and
+// version. Old versions stay registered so in-flight executions can replay.
+var journeys = map[string]map[int]JourneyDefinition{}
+
+// LoadJourneys reads every YAML/JSON journey definition in dir. Each file holds
+// one version of a journey; all versions are returned.
+func LoadJourneys(dir string) ([]JourneyDefinition, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var defs []JourneyDefinition
+	for _, file := range files {
+		ext := strings.TrimPrefix(filepath.Ext(file.Name()), ".")
+		if file.IsDir() || (ext != "yml" && ext != "yaml" && ext != "json") {
+			continue
+		}
+
+		v := viper.New()
+		v.SetConfigFile(filepath.Join(dir, file.Name()))
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name(), err)
+		}
+		var def JourneyDefinition
+		if err := v.Unmarshal(&def); err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name(), err)
+		}
+		if def.Name == "" {
+			return nil, fmt.Errorf("%s: journey without name", file.Name())
+		}
+
+		if def.Version < 1 {
+			return nil, fmt.Errorf("%s: journey %q needs a version of at least 1", file.Name(), def.Name)
+		}
+		key := fmt.Sprintf("%s@%d", def.Name, def.Version)
+		if seen[key] {
+			return nil, fmt.Errorf("%s: journey %q version %d defined twice", file.Name(), def.Name, def.Version)
+		}
+		seen[key] = true
+		defs = append(defs, def)
+	}
+
+	sort.Slice(defs, func(i, j int) bool {
+		if defs[i].Name != defs[j].Name {
+			return defs[i].Name < defs[j].Name
+		}
+		return defs[i].Version < defs[j].Version
+	})
+	return defs, nil
+}
+
+// validateJourney checks the steps of a journey or of each of its stages.
+func validateJourney(def JourneyDefinition) error {
+	if len(def.Steps) > 0 && len(def.Stages) > 0 {
+		return errors.New("both steps and stages defined")
+	}
+	if len(def.Stages) == 0 {
+		if err := validateSteps(def.Steps); err != nil {
+			return err
+		}
+		return validateConditions(def.Steps)
+	}
+	var steps []StepDefinition
+	for _, stage := range def.Stages {
+		if stage.Action == "" {
+			return errors.New("stage without action")
+		}
+		if err := validateSteps(stage.Steps); err != nil {
+			return fmt.Errorf("stage %q: %w", stage.Action, err)
+		}
+		steps = append(steps, stage.Steps...)
+	}
+	return validateConditions(steps)
+}
+
+// RegisterJourneys validates the given definitions and registers each journey
+// as a workflow type named after it. All versions of a journey share the
+// workflow type; the version an execution runs is pinned by runJourney.
+func RegisterJourneys(defs []JourneyDefinition) error {
+	for _, def := range defs {
+		if err := validateJourney(def); err != nil {
+			return fmt.Errorf("journey %q version %d: %w", def.Name, def.Version, err)
+		}
+	}
+
+	for _, def := range defs {
+		name := def.Name
+		if _, ok := journeys[name]; !ok {
+			journeys[name] = map[int]JourneyDefinition{}
+			workflow.RegisterWithOptions(func(ctx workflow.Context, applicantID string) (string, error) {
+				return runJourney(ctx, name, applicantID)
+			}, workflow.RegisterOptions{Name: name})
+		}
+		journeys[name][def.Version] = def
+	}
+	return nil
+}
+
+// WorkflowID returns the workflow ID of the journey of the given type for an
+// applicant, e.g. "setup:42". It lets clients find an applicant's execution
+// without remembering its IDs.
+func WorkflowID(journeyType string, applicantID string) string {
+	return journeyType + ":" + applicantID
+}
+
+// latestJourney returns the highest registered version of a journey.
+func latestJourney(name string) (JourneyDefinition, bool) {
+	var latest JourneyDefinition
+	for _, def := range journeys[name] {
+		if def.Version > latest.Version {
+			latest = def
+		}
+	}
+	return latest, latest.Version > 0
+}
+
+// journeyVersion pins the definition version an execution runs. New executions
+// record the latest version in their history through workflow.GetVersion and
+// keep replaying it after newer versions are deployed. Executions started
+// before versioning was introduced have no marker and run version 1.
+func journeyVersion(ctx workflow.Context, name string) (JourneyDefinition, error) {
+	latest, ok := latestJourney(name)
+	if !ok {
+		return JourneyDefinition{}, fmt.Errorf("journey %q is not registered", name)
+	}
+
+	version := int(workflow.GetVersion(ctx, "journey:"+name, workflow.DefaultVersion, workflow.Version(latest.Version)))
+	if version == int(workflow.DefaultVersion) {
+		version = 1
+	}
+
+	def, ok := journeys[name][version]
+	if !ok {
+		// Panicking fails the decision task instead of the workflow, so the
+		// execution resumes once the missing definition file is deployed again.
+		panic(fmt.Sprintf("journey %q version %d is not registered", name, version))
+	}
+	return def, nil
+}
+
+// journeyTimeout returns the execution timeout of the latest version of a
+// journey, or fallback when the journey does not define one.
+func journeyTimeout(name string, fallback time.Duration) time.Duration {
+	if def, ok := latestJourney(name); ok && def.Timeout > 0 {
+		return def.Timeout
+	}
+	return fallback
+}
+
+// runJourney runs the registered journey with the given name and exposes its
+// state through the "state" query.
+func runJourney(ctx workflow.Context, name string, applicantID string) (string, error) {
+	ctx = workflow.WithActivityOptions(ctx, activityOptions)
+
+	logger := workflow.GetLogger(ctx)
+	logger.Info("Journey workflow started", zap.String("journey", name))
+	logger.Info("Applicant ID: " + applicantID)
+
+	def, err := journeyVersion(ctx, name)
+	if err != nil {
+		logger.Error("Journey not registered.", zap.String("journey", name), zap.Error(err))
+		return "", err
+	}
+	logger.Info("Running journey version", zap.String("journey", name), zap.Int("version", def.Version))
+
+	if len(def.Stages) > 0 {
+		return runStagedJourney(ctx, def, applicantID)
+	}
+
+	workflowState := newWorkflowState(def.Steps)
+	workflowState.Journey = def.Name
+	workflowState.Version = def.Version
+
+	err = workflow.SetQueryHandler(ctx, "state", func(input []byte) (WorkflowState, error) {
+		return workflowState, nil
+	})
+	if err != nil {
+		logger.Info("SetQueryHandler failed: " + err.Error())
+	}
+
+	err = runSteps(ctx, applicantID, def.Steps, &workflowState, map[string]interface{}{})
+	if errors.Is(err, errJourneyExpired) {
+		return "Journey " + name + " expired", nil
+	}
+	if errors.Is(err, errApplicantRejected) {
+		return "Journey " + name + " rejected", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return "Journey " + name + " completed", nil
+}
+
+// runStagedJourney runs the stages of a journey one after the other and
+// exposes them through the "state" query as a status tree: the journey with
+// the stages started so far as its children, the current one last.
+func runStagedJourney(ctx workflow.Context, def JourneyDefinition, applicantID string) (string, error) {
+	logger := workflow.GetLogger(ctx)
+
+	stages := make([]StepDefinition, 0, len(def.Stages))
+	for _, stage := range def.Stages {
+		stages = append(stages, StepDefinition{Action: stage.Action})
+	}
+	parentState := newWorkflowState(stages)
+	parentState.Journey = def.Name
+	parentState.Version = def.Version
+	var stageStates []*WorkflowState
+
+	err := workflow.SetQueryHandler(ctx, "state", func(input []byte) (JourneyStatus, error) {
+		status := JourneyStatus{WorkflowState: parentState}
+		for _, stageState := range stageStates {
+			status.Children = append(status.Children, JourneyStatus{WorkflowState: *stageState})
+			status.Sequence += stageState.Sequence
+		}
+		return status, nil
+	})
+	if err != nil {
+		logger.Info("SetQueryHandler failed: " + err.Error())
+	}
+
+	// Payloads are shared by the stages so conditions can look at earlier stages.
+	payloads := map[string]interface{}{}
+	for parentState.Current.Status != StatusCompleted {
+		steps := def.Stages[parentState.Current.Index-1].Steps
+		stageState := newWorkflowState(steps)
+		stageStates = append(stageStates, &stageState)
+		err := runSteps(ctx, applicantID, steps, &stageState, payloads)
+		if errors.Is(err, errJourneyExpired) {
+			return "Journey " + def.Name + " expired", nil
+		}
+		if errors.Is(err, errApplicantRejected) {
+			return "Journey " + def.Name + " rejected", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		advance(&parentState)
+	}
+
+	return "Journey " + def.Name + " completed", nil
+}
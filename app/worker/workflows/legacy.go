@@ -0,0 +1,247 @@
+package workflows
+
+import (
+	"context"
+
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+)
+
+// legacyActivityPrefix is the package path the activities were registered
+// under before they took a StepInput.
+const legacyActivityPrefix = "github.com/BhanuChandraAraveti/cadence-example/app/worker/workflows."
+
+// legacyActivities are the activities with the signatures executions started
+// before the step runner schedule them with, by the names they were
+// registered under. Replay matches scheduled activities by name, and pending
+// tasks are decoded with these signatures, so they stay registered until those
+// executions have closed.
+var legacyActivities = map[string]interface{}{
+	"degreeDetailsActivity":     legacyDegreeDetailsActivity,
+	"watchVideoActivity":        legacyWatchVideoActivity,
+	"gradeActivity":             legacyGradeActivity,
+	"streamSelectionActivity":   legacyStreamSelectionActivity,
+	"teacherCETAndSOPActivity":  legacyTeacherCETAndSOPActivity,
+	"uploadLessonVideoActivity": legacyUploadLessonVideoActivity,
+	"submitDocumentsActivity":   legacySubmitDocumentsActivity,
+	"orientationActivity":       legacyOrientationActivity,
+	"basicDetailsActivity":      legacyBasicDetailsActivity,
+	"agreementActivity":         legacyAgreementActivity,
+	"profileActivity":           legacyProfileActivity,
+	"availabilityActivity":      legacyAvailabilityActivity,
+	"templateActivity":          legacyTemplateActivity,
+}
+
+// This is registration process where you register all your workflows
+// and activity function handlers.
+func init() {
+	for name, fn := range legacyActivities {
+		activity.RegisterWithOptions(fn, activity.RegisterOptions{Name: legacyActivityPrefix + name})
+	}
+}
+
+// onStepRunner reports whether the execution runs on the step runner.
+// Executions started before it keep running the code they started with.
+func onStepRunner(ctx workflow.Context) bool {
+	return workflow.GetVersion(ctx, "step-runner", workflow.DefaultVersion, 1) == 1
+}
+
+func legacyDegreeDetailsActivity(ctx context.Context, applicantID string, workflowID string, runID string) (string, error) {
+	return degreeDetailsActivity(ctx, StepInput{ApplicantID: applicantID, WorkflowID: workflowID, RunID: runID, Action: "degree-details"})
+}
+
+func legacyWatchVideoActivity(ctx context.Context) (string, error) {
+	return watchVideoActivity(ctx, StepInput{Action: "watch-video"})
+}
+
+func legacyGradeActivity(ctx context.Context) (string, error) {
+	return gradeActivity(ctx, StepInput{Action: "grade"})
+}
+
+func legacyStreamSelectionActivity(ctx context.Context) (string, error) {
+	return streamSelectionActivity(ctx, StepInput{Action: "stream-selection"})
+}
+
+func legacyTeacherCETAndSOPActivity(ctx context.Context) (string, error) {
+	return teacherCETAndSOPActivity(ctx, StepInput{Action: "cet-and-sop"})
+}
+
+func legacyUploadLessonVideoActivity(ctx context.Context) (string, error) {
+	return uploadLessonVideoActivity(ctx, StepInput{Action: "upload-lesson-video"})
+}
+
+func legacySubmitDocumentsActivity(ctx context.Context) (string, error) {
+	return submitDocumentsActivity(ctx, StepInput{Action: "submit-documents"})
+}
+
+func legacyOrientationActivity(ctx context.Context) (string, error) {
+	return orientationActivity(ctx, StepInput{Action: "orientation"})
+}
+
+func legacyBasicDetailsActivity(ctx context.Context) (string, error) {
+	return basicDetailsActivity(ctx, StepInput{Action: "basic-details"})
+}
+
+func legacyAgreementActivity(ctx context.Context) (string, error) {
+	return agreementActivity(ctx, StepInput{Action: "agreement"})
+}
+
+func legacyProfileActivity(ctx context.Context) (string, error) {
+	return profileActivity(ctx, StepInput{Action: "profile"})
+}
+
+func legacyAvailabilityActivity(ctx context.Context) (string, error) {
+	return availabilityActivity(ctx, StepInput{Action: "availability"})
+}
+
+func legacyTemplateActivity(ctx context.Context, name string) (string, error) {
+	return templateActivity(ctx, StepInput{Action: name})
+}
+
+// legacyScreen is a screen of a workflow written before the step runner: the
+// activity it scheduled, with the arguments it was scheduled with, and the
+// submission it waited for. Screens without an activity only wait.
+type legacyScreen struct {
+	action   string
+	activity interface{}
+	args     []interface{}
+	// persist sends the submission to the profile backend.
+	persist bool
+}
+
+// runLegacyScreens runs screens the way the workflows written before the step
+// runner did, so their executions replay. It returns the last submission.
+func runLegacyScreens(ctx workflow.Context, workflowState *WorkflowState, screens []legacyScreen) (Mystruct, error) {
+	ctx = workflow.WithActivityOptions(ctx, activityOptions)
+	logger := workflow.GetLogger(ctx)
+
+	index := map[string]int{}
+	for _, screen := range screens {
+		if _, ok := index[screen.action]; !ok {
+			index[screen.action] = len(workflowState.Steps)
+			workflowState.Steps = append(workflowState.Steps, WorkflowStep{Action: screen.action, Index: len(workflowState.Steps) + 1, Status: StatusNotStarted})
+		}
+	}
+
+	err := workflow.SetQueryHandler(ctx, "state", func(input []byte) (WorkflowState, error) {
+		return *workflowState, nil
+	})
+	if err != nil {
+		logger.Info("SetQueryHandler failed: " + err.Error())
+	}
+
+	var data Mystruct
+	signalChan := workflow.GetSignalChannel(ctx, SignalName)
+	for _, screen := range screens {
+		step := &workflowState.Steps[index[screen.action]]
+		step.Status = StatusInProgress
+		workflowState.Current = *step
+
+		if screen.activity != nil {
+			var activityResult string
+			err = workflow.ExecuteActivity(ctx, screen.activity, screen.args...).Get(ctx, &activityResult)
+			if err != nil {
+				logger.Error("Activity failed.", zap.String("action", screen.action), zap.Error(err))
+				return data, err
+			}
+		}
+
+		signalChan.Receive(ctx, &data)
+		workflowState.Sequence++
+		logger.Info("payload", zap.Any("data", data))
+		if screen.persist {
+			persistProfile(ctx, workflowState, screen.action, "update-profile", data)
+		}
+		step.Status = StatusCompleted
+		workflowState.Current = *step
+	}
+	return data, nil
+}
+
+func legacySetupWorkflow(ctx workflow.Context, applicantID string) (string, error) {
+	info := workflow.GetInfo(ctx)
+	workflowID := info.WorkflowExecution.ID
+	runID := info.WorkflowExecution.RunID
+	ids := []interface{}{applicantID, workflowID, runID}
+
+	var workflowState WorkflowState
+	_, err := runLegacyScreens(ctx, &workflowState, []legacyScreen{
+		{action: "basic-details", activity: legacyBasicDetailsActivity, args: ids, persist: true},
+		{action: "agreement", activity: legacyAgreementActivity, args: ids, persist: true},
+		// The agreement screen waited for a second submission.
+		{action: "agreement", persist: true},
+		{action: "profile", activity: legacyProfileActivity, args: ids, persist: true},
+		{action: "availability", activity: legacyAvailabilityActivity, args: ids, persist: true},
+	})
+	if err != nil {
+		return "", err
+	}
+	return "Teacher Setup Completed", nil
+}
+
+func legacyLeadWorkflow(ctx workflow.Context) (string, error) {
+	var workflowState WorkflowState
+	_, err := runLegacyScreens(ctx, &workflowState, []legacyScreen{
+		{action: "select-degree", activity: legacyTemplateActivity, args: []interface{}{"Select Degree"}},
+		{action: "select-stream", activity: legacyTemplateActivity, args: []interface{}{"Select Stream"}},
+		{action: "select-experience", activity: legacyProfileActivity, args: []interface{}{"Select Experience"}},
+	})
+	if err != nil {
+		return "", err
+	}
+	return "Teacher Setup Completed", nil
+}
+
+func legacyApplicationWorkflow(ctx workflow.Context) (string, error) {
+	var workflowState WorkflowState
+	_, err := runLegacyScreens(ctx, &workflowState, []legacyScreen{
+		{action: "watch-video", activity: legacyTemplateActivity, args: []interface{}{"Watch Video"}},
+		{action: "select-grade", activity: legacyTemplateActivity, args: []interface{}{"Select Grade"}},
+		{action: "screening", activity: legacyProfileActivity, args: []interface{}{"Screening"}},
+	})
+	if err != nil {
+		return "", err
+	}
+	return "Teacher Setup Completed", nil
+}
+
+func legacyWorkflow(ctx workflow.Context, applicantID string) (string, error) {
+	info := workflow.GetInfo(ctx)
+	workflowID := info.WorkflowExecution.ID
+	runID := info.WorkflowExecution.RunID
+
+	var workflowState WorkflowState
+	data, err := runLegacyScreens(ctx, &workflowState, []legacyScreen{
+		{action: "degree-details", activity: legacyDegreeDetailsActivity, args: []interface{}{applicantID, workflowID, runID}, persist: true},
+		{action: "stream-selection", activity: legacyStreamSelectionActivity, persist: true},
+		{action: "grade", activity: legacyGradeActivity, persist: true},
+		{action: "watch-video", activity: legacyWatchVideoActivity},
+		{action: "cet-and-sop", activity: legacyTeacherCETAndSOPActivity},
+		{action: "upload-lesson-video", activity: legacyUploadLessonVideoActivity},
+		{action: "submit-documents", activity: legacySubmitDocumentsActivity},
+	})
+	if err != nil {
+		return "", err
+	}
+	persistProfile(ctx, &workflowState, "submit-documents", "create-teacher", data)
+	return "Workflow completed.", nil
+}
+
+func legacyTeacherJourneyWorkflow(ctx workflow.Context) (string, error) {
+	var workflowState WorkflowState
+	_, err := runLegacyScreens(ctx, &workflowState, []legacyScreen{
+		{action: "select-degree", activity: legacyTemplateActivity, args: []interface{}{"Select Degree"}},
+		{action: "select-stream", activity: legacyTemplateActivity, args: []interface{}{"Select Stream"}},
+		{action: "select-experience", activity: legacyTemplateActivity, args: []interface{}{"Select Experience"}},
+		{action: "watch-video", activity: legacyTemplateActivity, args: []interface{}{"Watch Video"}},
+		{action: "select-grade", activity: legacyTemplateActivity, args: []interface{}{"Select Grade"}},
+		{action: "screening", activity: legacyTemplateActivity, args: []interface{}{"Screening"}},
+		// Screening waited for a second submission when it was done.
+		{action: "screening"},
+	})
+	if err != nil {
+		return "", err
+	}
+	return "Teacher Journey Completed", nil
+}
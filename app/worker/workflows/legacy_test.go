@@ -0,0 +1,49 @@
+package workflows
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/encoded"
+	"go.uber.org/cadence/workflow"
+)
+
+func (s *StepsTestSuite) activityNames() *[]string {
+	var names []string
+	s.env.SetOnActivityStartedListener(func(info *activity.Info, ctx context.Context, args encoded.Values) {
+		names = append(names, info.ActivityType.Name)
+	})
+	return &names
+}
+
+func (s *StepsTestSuite) Test_LegacyExecutionsKeepTheirActivities() {
+	s.env.OnGetVersion("step-runner", workflow.DefaultVersion, 1).Return(workflow.DefaultVersion)
+	names := s.activityNames()
+	for i := 0; i < 7; i++ {
+		s.submit("", nil)
+	}
+
+	s.env.ExecuteWorkflow(TeacherJourneyWorkflow)
+
+	s.Require().True(s.env.IsWorkflowCompleted())
+	s.Require().NoError(s.env.GetWorkflowError())
+	s.Require().Len(*names, 6)
+	for _, name := range *names {
+		s.Equal(legacyActivityPrefix+"templateActivity", name)
+	}
+}
+
+func (s *StepsTestSuite) Test_StepRunnerExecutionsUseStepActivities() {
+	s.env.OnActivity(orientationActivity, mock.Anything, mock.Anything).Return("Orientation activity ended", nil)
+	names := s.activityNames()
+	s.submit("orientation", nil)
+	s.submit("", nil)
+
+	s.env.ExecuteWorkflow(OrientationWorkflow, "applicant-1")
+
+	s.Require().True(s.env.IsWorkflowCompleted())
+	s.Require().NoError(s.env.GetWorkflowError())
+	s.Contains(*names, "orientationStepActivity")
+	s.NotContains(*names, legacyActivityPrefix+"orientationActivity")
+}
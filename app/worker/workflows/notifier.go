@@ -0,0 +1,58 @@
+package workflows
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+)
+
+// This is registration process where you register all your workflows
+// and activity function handlers.
+func init() {
+	activity.Register(sendReminderActivity)
+}
+
+// Reminder is sent to an applicant who has not submitted a step in time, or
+// to the Reviewer of an overdue review.
+type Reminder struct {
+	ApplicantID string    `json:"applicant_id,omitempty"`
+	WorkflowID  string    `json:"workflow_id,omitempty"`
+	Action      string    `json:"action"`
+	Reviewer    string    `json:"reviewer,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+// Notifier delivers reminders to applicants and reviewers, e.g. by email or
+// SMS.
+type Notifier interface {
+	Notify(ctx context.Context, reminder Reminder) error
+}
+
+// logNotifier only logs reminders. It is used until SetNotifier is called.
+type logNotifier struct{}
+
+func (logNotifier) Notify(ctx context.Context, reminder Reminder) error {
+	activity.GetLogger(ctx).Info("Reminder", zap.Any("reminder", reminder))
+	return nil
+}
+
+var notifier Notifier = logNotifier{}
+
+// SetNotifier sets the notifier used by the reminder activity. It must be
+// called before the worker starts.
+func SetNotifier(n Notifier) {
+	notifier = n
+}
+
+func sendReminderActivity(ctx context.Context, reminder Reminder) (string, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Reminder activity started")
+	if err := notifier.Notify(ctx, reminder); err != nil {
+		logger.Error("Reminder failed.", zap.Error(err))
+		return "", err
+	}
+	logger.Info("Reminder activity ended")
+	return "Reminder sent", nil
+}
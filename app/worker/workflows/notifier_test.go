@@ -0,0 +1,37 @@
+package workflows
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// recordingNotifier records the reminders it is given.
+type recordingNotifier struct {
+	mu        sync.Mutex
+	reminders []Reminder
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, reminder Reminder) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.reminders = append(n.reminders, reminder)
+	return nil
+}
+
+func (s *StepsTestSuite) Test_ReminderReachesNotifier() {
+	recorder := &recordingNotifier{}
+	SetNotifier(recorder)
+	defer SetNotifier(logNotifier{})
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(SignalName, Mystruct{Action: "first"})
+	}, 2*time.Hour)
+
+	result, state := s.run(StepDefinition{Action: "first", RemindAfter: time.Hour})
+
+	s.Equal("Step runner completed", result)
+	s.Require().Len(recorder.reminders, 1)
+	s.Equal("applicant-1", recorder.reminders[0].ApplicantID)
+	s.Equal("first", recorder.reminders[0].Action)
+	s.Equal(state.Reminders, recorder.reminders)
+}
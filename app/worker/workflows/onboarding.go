@@ -73,6 +73,10 @@ func OnboardingWorkflow(ctx workflow.Context, applicantID string) (string, error
 	selector.Select(ctx)
 	logger.Info("payload", zap.Any("data", data))
 	
+	// Executions started before forwarding was added let submit signals wait
+	// for the parent's last step.
+	forward := workflow.GetVersion(ctx, "forward-submit", workflow.DefaultVersion, 1) == 1
+
 	// Orientation Workflow
 	execution := workflow.GetInfo(ctx).WorkflowExecution
 	// Parent workflow can choose to specify it's own ID for child execution.  Make sure they are unique for each execution.
@@ -84,7 +88,10 @@ func OnboardingWorkflow(ctx workflow.Context, applicantID string) (string, error
 	}
 	ctx = workflow.WithChildOptions(ctx, cwo)
 	var result string
-	err = workflow.ExecuteChildWorkflow(ctx, OrientationWorkflow, applicantID).Get(ctx, &result)
+	future := executeChild(ctx, &workflowState, OrientationWorkflow, applicantID)
+	// The submission starting the journey is applied once orientation runs.
+	workflowState.Sequence++
+	err = waitForChild(ctx, future, forward, &result)
 	if err != nil {
 		logger.Error("Parent execution received child execution failure.", zap.Error(err))
 		return "", err
@@ -100,10 +107,10 @@ func OnboardingWorkflow(ctx workflow.Context, applicantID string) (string, error
 	childID = fmt.Sprintf("setup:%v", execution.RunID)
 	cwo = workflow.ChildWorkflowOptions{
 		WorkflowID:                   childID,
-		ExecutionStartToCloseTimeout: time.Hour,
+		ExecutionStartToCloseTimeout: journeyTimeout("setup", time.Hour),
 	}
 	ctx = workflow.WithChildOptions(ctx, cwo)
-	err = workflow.ExecuteChildWorkflow(ctx, SetupWorkflow, applicantID).Get(ctx, &result)
+	err = waitForChild(ctx, executeChild(ctx, &workflowState, SetupWorkflow, applicantID), forward, &result)
 	if err != nil {
 		logger.Error("Parent execution received child execution failure.", zap.Error(err))
 		return "", err
@@ -116,6 +123,7 @@ func OnboardingWorkflow(ctx workflow.Context, applicantID string) (string, error
 
 	selector.AddReceive(signalChan, func(c workflow.Channel, more bool) {
 		c.Receive(ctx, &data)
+		workflowState.Sequence++
 		workflow.GetLogger(ctx).Info("Received the signal!", zap.String("signal", signalName))
 	})
 	workflow.GetLogger(ctx).Info("Waiting for signal on channel.. " + signalName)
@@ -125,4 +133,52 @@ func OnboardingWorkflow(ctx workflow.Context, applicantID string) (string, error
 	logger.Info("payload", zap.Any("data", data))
 
 	return "Teacher Onboarding Completed", nil
-}
\ No newline at end of file
+}
+
+// executeChild starts the child workflow of the current step and records its
+// workflow and run IDs in the step once it is started, so status queries can
+// find the child.
+func executeChild(ctx workflow.Context, workflowState *WorkflowState, childWorkflow interface{}, args ...interface{}) workflow.ChildWorkflowFuture {
+	future := workflow.ExecuteChildWorkflow(ctx, childWorkflow, args...)
+	var childWE workflow.Execution
+	if err := future.GetChildWorkflowExecution().Get(ctx, &childWE); err != nil {
+		return future
+	}
+	index := workflowState.Current.Index
+	workflowState.Steps[index-1].WorkflowID = &childWE.ID
+	workflowState.Steps[index-1].RunID = &childWE.RunID
+	workflowState.Current = workflowState.Steps[index-1]
+	return future
+}
+
+// waitForChild waits for a child workflow to complete. When forward is set,
+// submit signals the parent receives in the meantime are forwarded to the
+// child, so clients can submit every step to the parent execution.
+func waitForChild(ctx workflow.Context, future workflow.ChildWorkflowFuture, forward bool, result interface{}) error {
+	if !forward {
+		return future.Get(ctx, result)
+	}
+
+	logger := workflow.GetLogger(ctx)
+	done := false
+	selector := workflow.NewSelector(ctx)
+	selector.AddFuture(future, func(f workflow.Future) {
+		done = true
+	})
+	selector.AddReceive(workflow.GetSignalChannel(ctx, SignalName), func(c workflow.Channel, more bool) {
+		var data Mystruct
+		c.Receive(ctx, &data)
+		// A child that completed in the meantime no longer takes signals;
+		// the submission is dropped like any other one for a closed step.
+		err := future.SignalChildWorkflow(ctx, SignalName, data).Get(ctx, nil)
+		if err != nil {
+			logger.Error("Forwarding signal to child failed.", zap.String("action", data.Action), zap.Error(err))
+			return
+		}
+		logger.Info("Forwarded the signal to child!", zap.String("signal", SignalName), zap.String("action", data.Action))
+	})
+	for !done {
+		selector.Select(ctx)
+	}
+	return future.Get(ctx, result)
+}
@@ -0,0 +1,46 @@
+package workflows
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func (s *StepsTestSuite) Test_OnboardingTracksChildExecutions() {
+	// Mocked children are reported started once they return.
+	s.env.OnWorkflow(OrientationWorkflow, mock.Anything, "applicant-1").Return("Orientation completed", nil).After(time.Hour)
+	// Setup runs within its built-in journey timeout of an hour.
+	s.env.OnWorkflow(SetupWorkflow, mock.Anything, "applicant-1").Return("Setup completed", nil).After(20 * time.Minute)
+	s.submit("orientation", nil)
+	var setup WorkflowState
+	s.env.RegisterDelayedCallback(func() {
+		value, err := s.env.QueryWorkflow("state")
+		s.Require().NoError(err)
+		s.Require().NoError(value.Get(&setup))
+	}, 70*time.Minute)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(SignalName, Mystruct{ApplicantId: "applicant-1", Action: "done"})
+	}, 3*time.Hour)
+
+	s.env.ExecuteWorkflow(OnboardingWorkflow, "applicant-1")
+
+	s.Require().True(s.env.IsWorkflowCompleted())
+	s.Require().NoError(s.env.GetWorkflowError())
+	value, err := s.env.QueryWorkflow("state")
+	s.Require().NoError(err)
+	var completed WorkflowState
+	s.Require().NoError(value.Get(&completed))
+	runID := "default-test-run-id"
+
+	s.Equal("setup", setup.Current.Action)
+	s.Equal([]string{StatusCompleted, StatusInProgress}, s.statuses(setup))
+	s.Require().NotNil(setup.Steps[0].WorkflowID)
+	s.Equal("orientation:"+runID, *setup.Steps[0].WorkflowID)
+	s.NotNil(setup.Steps[0].RunID)
+
+	s.Equal([]string{StatusCompleted, StatusCompleted}, s.statuses(completed))
+	s.Require().NotNil(completed.Steps[1].WorkflowID)
+	s.Equal("setup:"+runID, *completed.Steps[1].WorkflowID)
+	s.Equal(*completed.Steps[1].WorkflowID, *completed.Current.WorkflowID)
+	s.NotNil(completed.Steps[1].RunID)
+}
@@ -11,9 +11,14 @@ func init() {
 	workflow.Register(OrientationWorkflow)
 }
 
-type Response struct {
+// JourneyStatus is a node of the status tree of a journey: the state of a
+// workflow execution, or of a stage of a staged journey, with the child
+// executions or stages under it. Stages run in the execution of their parent
+// and have no workflow ID of their own.
+type JourneyStatus struct {
 	Execution
-	WorkflowState WorkflowState `json:"workflow_state"`
+	WorkflowState
+	Children []JourneyStatus `json:"children,omitempty"`
 }
 
 // type Execution struct {
@@ -23,8 +28,26 @@ type Response struct {
 
 
 type WorkflowState struct {
-    Current WorkflowStep   `json:"current"`
-    Steps   []WorkflowStep `json:"steps"`
+    Journey    string         `json:"journey,omitempty"`
+    Version    int            `json:"version,omitempty"`
+    Current    WorkflowStep   `json:"current"`
+    Steps      []WorkflowStep `json:"steps"`
+    Rejections []Rejection    `json:"rejections,omitempty"`
+    Rewinds    []Rewind       `json:"rewinds,omitempty"`
+    Reminders  []Reminder     `json:"reminders,omitempty"`
+    Dormant    bool           `json:"dormant,omitempty"`
+    // ProfileUpdates records the backend calls made for submitted steps.
+    ProfileUpdates []ProfileUpdate `json:"profile_updates,omitempty"`
+    Evaluations    []Evaluation    `json:"evaluations,omitempty"`
+    Reviews        []ReviewTask    `json:"reviews,omitempty"`
+    Uploads        []UploadTask    `json:"uploads,omitempty"`
+    Documents      []DocumentStatus `json:"documents,omitempty"`
+    Agreements     []AgreementRecord `json:"agreements,omitempty"`
+    // Schedules are the normalized availability of availability steps.
+    Schedules      []Schedule       `json:"schedules,omitempty"`
+    // Sequence counts the submissions the execution has processed, accepted
+    // or rejected. Clients compare it to wait for a submission to be applied.
+    Sequence       int              `json:"sequence"`
 }
 
 type WorkflowStep struct {
@@ -67,7 +90,15 @@ func OrientationWorkflow(ctx workflow.Context, applicantID string) (string, erro
 	}
 
 	var activityResult string
-	err = workflow.ExecuteActivity(ctx, orientationActivity, applicantID, workflowID, runID).Get(ctx, &activityResult)
+	// Executions started before the step runner schedule the activity with
+	// the arguments they started with.
+	var activityFuture workflow.Future
+	if onStepRunner(ctx) {
+		activityFuture = workflow.ExecuteActivity(withActivityProfile(ctx, "orientation"), orientationActivity, StepInput{ApplicantID: applicantID, WorkflowID: workflowID, RunID: runID, Action: "orientation"})
+	} else {
+		activityFuture = workflow.ExecuteActivity(ctx, legacyOrientationActivity, applicantID, workflowID, runID)
+	}
+	err = activityFuture.Get(ctx, &activityResult)
 	if err != nil {
 		logger.Error("Degree Details Activity failed.", zap.Error(err))
 		return "", err
@@ -77,12 +108,15 @@ func OrientationWorkflow(ctx workflow.Context, applicantID string) (string, erro
   	selector := workflow.NewSelector(ctx)
  	var data Mystruct
 	signalChan := workflow.GetSignalChannel(ctx, signalName)
+	// Executions started before the profile was persisted once persist it in
+	// the signal callback as well.
+	persistOnce := workflow.GetVersion(ctx, "persist-once", workflow.DefaultVersion, 1) == 1
 	selector.AddReceive(signalChan, func(c workflow.Channel, more bool) {
 		c.Receive(ctx, &data)
-		var msg1 string
-		msg1, err = call(data)
-		workflowState.Current.Status = msg1
-		//workflowState.Current.Status = "COMPLETED"
+		if !persistOnce {
+			persistProfile(ctx, &workflowState, "orientation", "update-profile", data)
+		}
+		workflowState.Current.Status = "COMPLETED"
 		workflowState.Steps[0].Status = "COMPLETED"
 		workflow.GetLogger(ctx).Info("Received the signal!", zap.String("signal", signalName))
 	})
@@ -94,11 +128,13 @@ func OrientationWorkflow(ctx workflow.Context, applicantID string) (string, erro
 
 	// call BE API
 	var msg string
-	msg, err = call(data)
+	msg = persistProfile(ctx, &workflowState, "orientation", "update-profile", data)
 	logger.Info(msg)
+	workflowState.Sequence++
 
 	selector.AddReceive(signalChan, func(c workflow.Channel, more bool) {
 		c.Receive(ctx, &data)
+		workflowState.Sequence++
 		workflow.GetLogger(ctx).Info("Received the signal!", zap.String("signal", signalName))
 	})
 	workflow.GetLogger(ctx).Info("Waiting for signal on channel.. " + signalName)
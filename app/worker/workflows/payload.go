@@ -0,0 +1,161 @@
+package workflows
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FieldSchema describes one field of a step payload.
+type FieldSchema struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required,omitempty"`
+	Enum     []string `json:"enum,omitempty"`
+}
+
+// Rejection records a submission the workflow refused to advance on.
+type Rejection struct {
+	Action string    `json:"action"`
+	Reason string    `json:"reason"`
+	Time   time.Time `json:"time"`
+}
+
+// payloadTypes are the field types a FieldSchema can declare.
+var payloadTypes = map[string]bool{
+	"string":  true,
+	"number":  true,
+	"boolean": true,
+	"object":  true,
+	"array":   true,
+}
+
+// validateSchema makes sure a step payload schema only uses known types.
+func validateSchema(schema []FieldSchema) error {
+	for _, field := range schema {
+		if field.Name == "" {
+			return fmt.Errorf("payload field without name")
+		}
+		if !payloadTypes[field.Type] {
+			return fmt.Errorf("payload field %q: unknown type %q", field.Name, field.Type)
+		}
+	}
+	return nil
+}
+
+// ValidatePayload checks a submitted payload against the schema of a step.
+// Steps without a schema accept any payload. Fields not in the schema are
+// rejected.
+func ValidatePayload(schema []FieldSchema, payload interface{}) error {
+	if len(schema) == 0 {
+		return nil
+	}
+
+	fields, ok := payload.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("payload must be an object")
+	}
+
+	known := map[string]bool{}
+	for _, field := range schema {
+		known[field.Name] = true
+		value, present := fields[field.Name]
+		if !present || value == nil {
+			if field.Required {
+				return fmt.Errorf("field %q is required", field.Name)
+			}
+			continue
+		}
+		if err := validateField(field, value); err != nil {
+			return err
+		}
+	}
+
+	var unknown []string
+	for name := range fields {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown fields: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func validateField(field FieldSchema, value interface{}) error {
+	switch field.Type {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("field %q must be a string", field.Name)
+		}
+		if len(field.Enum) > 0 {
+			for _, allowed := range field.Enum {
+				if s == allowed {
+					return nil
+				}
+			}
+			return fmt.Errorf("field %q must be one of %s", field.Name, strings.Join(field.Enum, ", "))
+		}
+	case "number":
+		switch value.(type) {
+		case float64, float32, int, int32, int64, json.Number:
+		default:
+			return fmt.Errorf("field %q must be a number", field.Name)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("field %q must be a boolean", field.Name)
+		}
+	case "object":
+		if _, ok := value.(map[string]interface{}); !ok {
+			return fmt.Errorf("field %q must be an object", field.Name)
+		}
+	case "array":
+		if _, ok := value.([]interface{}); !ok {
+			return fmt.Errorf("field %q must be an array", field.Name)
+		}
+	}
+	return nil
+}
+
+// FindStep returns the definition of the step with the given action in a
+// version of a journey, looking into stages as well.
+func FindStep(defs []JourneyDefinition, journey string, version int, action string) (StepDefinition, bool) {
+	for _, def := range defs {
+		if def.Name != journey || def.Version != version {
+			continue
+		}
+		steps := def.Steps
+		for _, stage := range def.Stages {
+			steps = append(steps, stage.Steps...)
+		}
+		for _, step := range steps {
+			if step.Action == action {
+				return step, true
+			}
+		}
+	}
+	return StepDefinition{}, false
+}
+
+// FirstStep returns the first step of the latest version of a journey in defs.
+func FirstStep(defs []JourneyDefinition, journey string) (StepDefinition, bool) {
+	var latest JourneyDefinition
+	for _, def := range defs {
+		if def.Name == journey && def.Version > latest.Version {
+			latest = def
+		}
+	}
+	if len(latest.Steps) > 0 {
+		return latest.Steps[0], true
+	}
+	if len(latest.Stages) > 0 && len(latest.Stages[0].Steps) > 0 {
+		return latest.Stages[0].Steps[0], true
+	}
+	return StepDefinition{}, false
+}
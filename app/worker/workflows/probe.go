@@ -0,0 +1,163 @@
+package workflows
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+// knownContainers are the containers HeaderProbe recognizes, as used in
+// UploadDefinition.Containers.
+var knownContainers = map[string]bool{
+	"mp4":  true,
+	"mov":  true,
+	"webm": true,
+	"mkv":  true,
+}
+
+// HeaderProbe recognizes MP4, QuickTime, WebM and Matroska files from their
+// headers. It reads the duration of MP4 and QuickTime files from their movie
+// header; for WebM and Matroska files the duration is left zero.
+type HeaderProbe struct{}
+
+var matroskaMagic = []byte{0x1a, 0x45, 0xdf, 0xa3}
+
+// Probe reads r up to the movie header of MP4 and QuickTime files, or only
+// the first bytes of other files.
+func (HeaderProbe) Probe(ctx context.Context, r io.Reader) (VideoInfo, error) {
+	header := make([]byte, 64)
+	n, err := io.ReadFull(r, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return VideoInfo{}, errors.New("file too short")
+	}
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, matroskaMagic):
+		// The DocType of the EBML header names WebM files.
+		if bytes.Contains(header, []byte("webm")) {
+			return VideoInfo{Container: "webm"}, nil
+		}
+		return VideoInfo{Container: "mkv"}, nil
+	case len(header) >= 12 && string(header[4:8]) == "ftyp":
+		video := VideoInfo{Container: "mp4"}
+		if string(header[8:12]) == "qt  " {
+			video.Container = "mov"
+		}
+		duration, err := mp4Duration(io.MultiReader(bytes.NewReader(header), r))
+		if err != nil {
+			return VideoInfo{}, err
+		}
+		video.Duration = duration
+		return video, nil
+	}
+	return VideoInfo{}, errors.New("unknown container")
+}
+
+// mp4Duration walks the top-level boxes of an MP4 or QuickTime file to the
+// movie header and returns the duration it records.
+func mp4Duration(r io.Reader) (time.Duration, error) {
+	for {
+		boxType, size, err := readBoxHeader(r)
+		if err == io.EOF {
+			return 0, errors.New("no movie header")
+		}
+		if err != nil {
+			return 0, err
+		}
+		if boxType != "moov" {
+			if size < 0 {
+				return 0, errors.New("no movie header")
+			}
+			if _, err := io.CopyN(ioutil.Discard, r, size); err != nil {
+				return 0, errors.New("truncated file")
+			}
+			continue
+		}
+
+		moov := r
+		if size >= 0 {
+			moov = io.LimitReader(r, size)
+		}
+		for {
+			boxType, size, err := readBoxHeader(moov)
+			if err != nil {
+				return 0, errors.New("no movie header")
+			}
+			if boxType == "mvhd" {
+				return mvhdDuration(moov)
+			}
+			if size < 0 {
+				return 0, errors.New("no movie header")
+			}
+			if _, err := io.CopyN(ioutil.Discard, moov, size); err != nil {
+				return 0, errors.New("truncated file")
+			}
+		}
+	}
+}
+
+// readBoxHeader reads the header of a box and returns its type and the size
+// of its content, or -1 for a box extending to the end of the file.
+func readBoxHeader(r io.Reader) (string, int64, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return "", 0, errors.New("truncated file")
+		}
+		return "", 0, err
+	}
+	size := int64(binary.BigEndian.Uint32(header[:4]))
+	boxType := string(header[4:8])
+	switch size {
+	case 0:
+		return boxType, -1, nil
+	case 1:
+		large := make([]byte, 8)
+		if _, err := io.ReadFull(r, large); err != nil {
+			return "", 0, errors.New("truncated file")
+		}
+		size = int64(binary.BigEndian.Uint64(large)) - 16
+	default:
+		size -= 8
+	}
+	if size < 0 {
+		return "", 0, fmt.Errorf("invalid %q box", boxType)
+	}
+	return boxType, size, nil
+}
+
+// mvhdDuration reads the duration from the content of a movie header box.
+func mvhdDuration(r io.Reader) (time.Duration, error) {
+	version := make([]byte, 4)
+	if _, err := io.ReadFull(r, version); err != nil {
+		return 0, errors.New("truncated movie header")
+	}
+	// Creation and modification times precede the time scale and duration.
+	fields := make([]byte, 16)
+	if version[0] == 1 {
+		fields = make([]byte, 28)
+	}
+	if _, err := io.ReadFull(r, fields); err != nil {
+		return 0, errors.New("truncated movie header")
+	}
+
+	var timescale uint32
+	var duration uint64
+	if version[0] == 1 {
+		timescale = binary.BigEndian.Uint32(fields[16:20])
+		duration = binary.BigEndian.Uint64(fields[20:28])
+	} else {
+		timescale = binary.BigEndian.Uint32(fields[8:12])
+		duration = uint64(binary.BigEndian.Uint32(fields[12:16]))
+	}
+	if timescale == 0 {
+		return 0, errors.New("invalid movie header")
+	}
+	return time.Duration(float64(duration) / float64(timescale) * float64(time.Second)), nil
+}
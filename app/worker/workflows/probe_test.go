@@ -0,0 +1,110 @@
+package workflows
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// box encodes an MP4 box of boxType with content.
+func box(boxType string, content ...[]byte) []byte {
+	body := bytes.Join(content, nil)
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(body)+8))
+	copy(header[4:], boxType)
+	return append(header, body...)
+}
+
+// largeBox encodes a box with a 64-bit size.
+func largeBox(boxType string, content []byte) []byte {
+	header := make([]byte, 16)
+	binary.BigEndian.PutUint32(header, 1)
+	copy(header[4:], boxType)
+	binary.BigEndian.PutUint64(header[8:], uint64(len(content)+16))
+	return append(header, content...)
+}
+
+// mvhd encodes a version 0 movie header.
+func mvhd(timescale, duration uint32) []byte {
+	content := make([]byte, 20)
+	binary.BigEndian.PutUint32(content[12:], timescale)
+	binary.BigEndian.PutUint32(content[16:], duration)
+	return box("mvhd", content)
+}
+
+// mvhdV1 encodes a version 1 movie header.
+func mvhdV1(timescale uint32, duration uint64) []byte {
+	content := make([]byte, 32)
+	content[0] = 1
+	binary.BigEndian.PutUint32(content[20:], timescale)
+	binary.BigEndian.PutUint64(content[24:], duration)
+	return box("mvhd", content)
+}
+
+func ftyp(brand string) []byte {
+	return box("ftyp", []byte(brand), make([]byte, 4), []byte("isom"))
+}
+
+func TestHeaderProbe(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  []byte
+		video VideoInfo
+		err   string
+	}{
+		{
+			name:  "mp4",
+			file:  bytes.Join([][]byte{ftyp("isom"), box("free", make([]byte, 100)), box("moov", box("trak"), mvhd(1000, 90500))}, nil),
+			video: VideoInfo{Container: "mp4", Duration: 90500 * time.Millisecond},
+		},
+		{
+			name:  "quicktime with version 1 header",
+			file:  bytes.Join([][]byte{ftyp("qt  "), box("moov", mvhdV1(600, 600*60*3))}, nil),
+			video: VideoInfo{Container: "mov", Duration: 3 * time.Minute},
+		},
+		{
+			name:  "large media box before the movie",
+			file:  bytes.Join([][]byte{ftyp("isom"), largeBox("mdat", make([]byte, 64)), box("moov", mvhd(25, 50))}, nil),
+			video: VideoInfo{Container: "mp4", Duration: 2 * time.Second},
+		},
+		{
+			name:  "webm",
+			file:  append([]byte{0x1a, 0x45, 0xdf, 0xa3, 0x9f, 0x42, 0x82, 0x84}, []byte("webm")...),
+			video: VideoInfo{Container: "webm"},
+		},
+		{
+			name:  "matroska",
+			file:  append([]byte{0x1a, 0x45, 0xdf, 0xa3, 0xa3, 0x42, 0x82, 0x88}, []byte("matroska")...),
+			video: VideoInfo{Container: "mkv"},
+		},
+		{name: "no movie header", file: bytes.Join([][]byte{ftyp("isom"), box("mdat", make([]byte, 16))}, nil), err: "no movie header"},
+		{name: "truncated box", file: append(ftyp("isom"), box("mdat", make([]byte, 16))[:12]...), err: "truncated file"},
+		{name: "zero time scale", file: bytes.Join([][]byte{ftyp("isom"), box("moov", mvhd(0, 10))}, nil), err: "invalid movie header"},
+		{name: "unknown container", file: []byte("GIF89a, not a video at all"), err: "unknown container"},
+		{name: "empty", file: nil, err: "file too short"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			video, err := HeaderProbe{}.Probe(context.Background(), bytes.NewReader(test.file))
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.video, video)
+		})
+	}
+}
+
+func TestCheckVideo(t *testing.T) {
+	limits := UploadDefinition{Containers: []string{"mp4", "webm"}, MaxDuration: time.Minute}
+
+	require.NoError(t, checkVideo(VideoInfo{Container: "mp4", Duration: 59 * time.Second}, limits))
+	require.NoError(t, checkVideo(VideoInfo{Container: "webm"}, limits), "unknown durations are not checked")
+	require.EqualError(t, checkVideo(VideoInfo{Container: "mkv"}, limits), `container "mkv" not accepted`)
+	require.EqualError(t, checkVideo(VideoInfo{Container: "mp4", Duration: 2 * time.Minute}, limits), "video of 2m0s exceeds 1m0s")
+}
@@ -0,0 +1,150 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/adapters/profileadapter"
+
+	"go.uber.org/cadence"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+)
+
+// This is registration process where you register all your workflows
+// and activity function handlers.
+func init() {
+	activity.Register(persistProfileActivity)
+}
+
+// ProfileBackend is the applicant-profile service the journeys report
+// progress to.
+type ProfileBackend interface {
+	// PatchProfile sets profile attributes of an applicant.
+	PatchProfile(ctx context.Context, applicantID string, attributes interface{}) (string, error)
+	// AttachWorkflow records the workflow execution driving an applicant's
+	// journey.
+	AttachWorkflow(ctx context.Context, applicantID string, workflowID string, runID string) (string, error)
+	// CreateTeacher turns the applicant into a teacher.
+	CreateTeacher(ctx context.Context, applicantID string) (string, error)
+}
+
+// profileBackend is unset until SetProfileBackend is called; backend calls
+// fail with errNoProfileBackend until then.
+var profileBackend ProfileBackend
+
+var errNoProfileBackend = errors.New("no profile backend set: call SetProfileBackend before starting the worker")
+
+// SetProfileBackend sets the backend the journeys report to. It must be
+// called before the worker starts.
+func SetProfileBackend(b ProfileBackend) {
+	profileBackend = b
+}
+
+// backendCalls maps the backend names used in step definitions to the calls
+// made with the submitted payload.
+var backendCalls = map[string]func(ctx context.Context, applicantID string, attributes interface{}) (string, error){
+	"update-profile": func(ctx context.Context, applicantID string, attributes interface{}) (string, error) {
+		if profileBackend == nil {
+			return "", errNoProfileBackend
+		}
+		return profileBackend.PatchProfile(ctx, applicantID, attributes)
+	},
+	"create-teacher": func(ctx context.Context, applicantID string, attributes interface{}) (string, error) {
+		if profileBackend == nil {
+			return "", errNoProfileBackend
+		}
+		return profileBackend.CreateTeacher(ctx, applicantID)
+	},
+}
+
+// profileActivityOptions are the built-in "backend" activity profile. They
+// retry backend calls, e.g. while the admin host is briefly unavailable.
+var profileActivityOptions = workflow.ActivityOptions{
+	ScheduleToStartTimeout: time.Minute,
+	StartToCloseTimeout:    time.Minute,
+	RetryPolicy: &cadence.RetryPolicy{
+		InitialInterval:          time.Second,
+		BackoffCoefficient:       2.0,
+		MaximumInterval:          time.Minute,
+		ExpirationInterval:       time.Minute * 5,
+		MaximumAttempts:          5,
+		NonRetriableErrorReasons: []string{ReasonHTTPClientError},
+	},
+}
+
+// ProfileRequest is the input of persistProfileActivity.
+type ProfileRequest struct {
+	ApplicantID string      `json:"applicant_id"`
+	Backend     string      `json:"backend"`
+	Attributes  interface{} `json:"attributes,omitempty"`
+}
+
+// ProfileUpdate records the outcome of a backend call made for a step.
+type ProfileUpdate struct {
+	Action  string    `json:"action"`
+	Backend string    `json:"backend"`
+	Status  string    `json:"status,omitempty"`
+	Error   string    `json:"error,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+func persistProfileActivity(ctx context.Context, request ProfileRequest) (string, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Persist profile activity started", zap.String("backend", request.Backend))
+	if profileBackend == nil {
+		return "", errNoProfileBackend
+	}
+	result, err := newActivityCalls(ctx).run(request.Backend, func() (CallResult, error) {
+		status, err := backendCalls[request.Backend](ctx, request.ApplicantID, request.Attributes)
+		return CallResult{Status: status}, backendError(err)
+	})
+	if err != nil {
+		logger.Error("Persist profile failed.", zap.String("status", result.Status), zap.Error(err))
+		return result.Status, err
+	}
+	logger.Info("Persist profile activity ended", zap.String("status", result.Status))
+	return result.Status, nil
+}
+
+// backendError classifies a backend failure like a failed activity call, so
+// rejected requests are not retried.
+func backendError(err error) error {
+	var statusErr *profileadapter.StatusError
+	if errors.As(err, &statusErr) {
+		return statusError(statusErr.StatusCode, statusErr.Status)
+	}
+	if err != nil {
+		return cadence.NewCustomError(ReasonHTTPRetriable, err.Error())
+	}
+	return nil
+}
+
+// persistProfile makes the backend call for the submission data of a step
+// and records its outcome in workflowState. The call runs as an activity and
+// is retried; one that still fails is recorded and the journey goes on.
+// Executions started before the call became an activity keep making it
+// inline.
+func persistProfile(ctx workflow.Context, workflowState *WorkflowState, action string, backend string, data Mystruct) string {
+	logger := workflow.GetLogger(ctx)
+
+	if workflow.GetVersion(ctx, "persist-profile-activity", workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		status, err := backendCalls[backend](context.Background(), data.ApplicantId, data.Payload)
+		if err != nil {
+			logger.Error("Backend call failed.", zap.String("action", action), zap.Error(err))
+		}
+		return status
+	}
+
+	update := ProfileUpdate{Action: action, Backend: backend, Time: workflow.Now(ctx)}
+	request := ProfileRequest{ApplicantID: data.ApplicantId, Backend: backend, Attributes: data.Payload}
+	err := workflow.ExecuteActivity(withActivityProfile(ctx, "persist-profile"), persistProfileActivity, request).Get(ctx, &update.Status)
+	if err != nil {
+		logger.Error("Backend call failed.", zap.String("action", action), zap.Error(err))
+		update.Error = err.Error()
+	}
+	workflowState.ProfileUpdates = append(workflowState.ProfileUpdates, update)
+	return update.Status
+}
@@ -0,0 +1,76 @@
+package workflows
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/adapters/profileadapter"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/cadence"
+	"go.uber.org/cadence/workflow"
+)
+
+// withProfileBackend sets the profile backend for a test and returns a
+// function restoring the previous one.
+func withProfileBackend(b ProfileBackend) func() {
+	previous := profileBackend
+	SetProfileBackend(b)
+	return func() { SetProfileBackend(previous) }
+}
+
+func (s *StepsTestSuite) Test_BackendCallsReachProfileBackend() {
+	backend := profileadapter.NewFake()
+	defer withProfileBackend(backend)()
+	s.submit("personal-info", map[string]interface{}{"name": "Asha"})
+
+	_, state := s.run(StepDefinition{Action: "personal-info", Backend: "update-profile"})
+
+	s.Require().Len(state.ProfileUpdates, 1)
+	s.Equal("200 OK", state.ProfileUpdates[0].Status)
+	s.Empty(state.ProfileUpdates[0].Error)
+	s.Equal([]interface{}{map[string]interface{}{"name": "Asha"}}, backend.Patches("applicant-1"))
+}
+
+func (s *StepsTestSuite) Test_BackendCallsFailWithoutBackend() {
+	defer withProfileBackend(nil)()
+	s.submit("personal-info", map[string]interface{}{"name": "Asha"})
+
+	result, state := s.run(StepDefinition{Action: "personal-info", Backend: "update-profile"})
+
+	s.Equal("Step runner completed", result)
+	s.Require().Len(state.ProfileUpdates, 1)
+	s.Contains(state.ProfileUpdates[0].Error, "SetProfileBackend")
+}
+
+func (s *StepsTestSuite) Test_InlineBackendCallsFailWithoutBackend() {
+	defer withProfileBackend(nil)()
+	s.env.OnGetVersion("persist-profile-activity", workflow.DefaultVersion, 1).Return(workflow.DefaultVersion)
+	s.submit("personal-info", map[string]interface{}{"name": "Asha"})
+
+	result, state := s.run(StepDefinition{Action: "personal-info", Backend: "update-profile"})
+
+	s.Equal("Step runner completed", result)
+	s.Empty(state.ProfileUpdates, "inline calls are not recorded")
+}
+
+func TestBackendErrorClassification(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		reason string
+	}{
+		{"rejected", &profileadapter.StatusError{StatusCode: 422, Status: "422 Unprocessable Entity"}, ReasonHTTPClientError},
+		{"throttled", &profileadapter.StatusError{StatusCode: 429, Status: "429 Too Many Requests"}, ReasonHTTPRetriable},
+		{"unavailable", &profileadapter.StatusError{StatusCode: 503, Status: "503 Service Unavailable"}, ReasonHTTPRetriable},
+		{"unreachable", errors.New("connection refused"), ReasonHTTPRetriable},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var customErr *cadence.CustomError
+			require.True(t, errors.As(backendError(test.err), &customErr))
+			require.Equal(t, test.reason, customErr.Reason())
+		})
+	}
+	require.NoError(t, backendError(nil))
+}
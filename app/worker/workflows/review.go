@@ -0,0 +1,254 @@
+package workflows
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+)
+
+// Signals reviewers send to a review step.
+const (
+	ClaimSignalName   = "claim"
+	ApproveSignalName = "approve"
+	RejectSignalName  = "reject"
+)
+
+// Review task statuses used in ReviewTask.Status.
+const (
+	ReviewPending  = "PENDING"
+	ReviewClaimed  = "CLAIMED"
+	ReviewApproved = "APPROVED"
+	ReviewRejected = "REJECTED"
+)
+
+// Search attributes a review step keeps up to date, so reviews can be listed
+// with a visibility query. The Cadence cluster must know them as keyword
+// attributes.
+const (
+	ReviewStatusAttribute   = "ReviewStatus"
+	ReviewAssigneeAttribute = "ReviewAssignee"
+	ReviewerAttribute       = "Reviewer"
+)
+
+// eventOverdue is the event of a review task passing its due time.
+const eventOverdue = "overdue"
+
+// reviewSearchAttributes turns on the review search attributes. They need
+// advanced visibility with the attributes added to the cluster; otherwise
+// upserting them fails the decision task and the journey stops.
+var reviewSearchAttributes bool
+
+// SetReviewSearchAttributes sets whether review steps record their task in
+// the review search attributes.
+func SetReviewSearchAttributes(enabled bool) {
+	reviewSearchAttributes = enabled
+}
+
+// ReviewDefinition turns a step into a human review: instead of waiting for
+// the applicant, the step waits for a reviewer to approve or reject. Assignee
+// is the reviewer or team the task is assigned to; the task is due DueAfter
+// after it is created, when it is marked overdue and its reviewer reminded.
+type ReviewDefinition struct {
+	Assignee string        `json:"assignee,omitempty"`
+	DueAfter time.Duration `json:"due_after,omitempty"`
+}
+
+// ReviewTask is a review created by a review step.
+type ReviewTask struct {
+	Action   string     `json:"action"`
+	Assignee string     `json:"assignee,omitempty"`
+	Created  time.Time  `json:"created"`
+	Due      *time.Time `json:"due,omitempty"`
+	Status   string     `json:"status"`
+	Reviewer string     `json:"reviewer,omitempty"`
+	Notes    string     `json:"notes,omitempty"`
+	Decided  *time.Time `json:"decided,omitempty"`
+	Overdue  bool       `json:"overdue,omitempty"`
+}
+
+// Open reports whether the task still waits for a decision.
+func (t ReviewTask) Open() bool {
+	return t.Status == ReviewPending || t.Status == ReviewClaimed
+}
+
+// ReviewSignal is the payload of the claim, approve and reject signals.
+type ReviewSignal struct {
+	WorkflowId string `json:"workflowId"`
+	RunId      string `json:"runId"`
+	Action     string `json:"action"`
+	Reviewer   string `json:"reviewer"`
+	Notes      string `json:"notes,omitempty"`
+}
+
+// validateReview checks the review settings of a step. Review steps are
+// completed by reviewers, so they take no applicant payload or deadlines.
+func validateReview(step StepDefinition) error {
+	if step.Review == nil {
+		return nil
+	}
+	if len(step.Payload) > 0 || step.Evaluate != nil || step.Signal != "" {
+		return errors.New("review steps take no payload, evaluate or signal")
+	}
+	if step.RemindAfter > 0 || step.ExpireAfter > 0 {
+		return errors.New("review steps take no remindAfter or expireAfter")
+	}
+	if step.Review.DueAfter < 0 {
+		return errors.New("negative dueAfter")
+	}
+	return nil
+}
+
+// openReview returns the review task the journey is waiting on, if any.
+func openReview(workflowState *WorkflowState) *ReviewTask {
+	if n := len(workflowState.Reviews); n > 0 && workflowState.Reviews[n-1].Open() {
+		return &workflowState.Reviews[n-1]
+	}
+	return nil
+}
+
+// waitForReview creates a review task for step i and waits until a reviewer
+// decides on it. Signals that do not fit the task are recorded as rejections.
+// It returns the decision as the step payload, or errApplicantRejected.
+func (r *stepRunner) waitForReview(i int) (map[string]interface{}, error) {
+	logger := workflow.GetLogger(r.ctx)
+	step := r.steps[i]
+
+	now := workflow.Now(r.ctx)
+	task := ReviewTask{
+		Action:   step.Action,
+		Assignee: step.Review.Assignee,
+		Created:  now,
+		Status:   ReviewPending,
+	}
+	if step.Review.DueAfter > 0 {
+		due := now.Add(step.Review.DueAfter)
+		task.Due = &due
+	}
+	r.state.Reviews = append(r.state.Reviews, task)
+	review := &r.state.Reviews[len(r.state.Reviews)-1]
+	r.upsertReview(*review)
+
+	var data ReviewSignal
+	event := ""
+	selector := workflow.NewSelector(r.ctx)
+	for _, name := range []string{ClaimSignalName, ApproveSignalName, RejectSignalName} {
+		name := name
+		selector.AddReceive(workflow.GetSignalChannel(r.ctx, name), func(c workflow.Channel, more bool) {
+			c.Receive(r.ctx, &data)
+			event = name
+			logger.Info("Received the signal!", zap.String("signal", name), zap.String("reviewer", data.Reviewer))
+		})
+	}
+	timerCtx, cancelTimer := workflow.WithCancel(r.ctx)
+	defer cancelTimer()
+	if step.Review.DueAfter > 0 {
+		selector.AddFuture(workflow.NewTimer(timerCtx, step.Review.DueAfter), func(f workflow.Future) {
+			if f.Get(timerCtx, nil) == nil {
+				event = eventOverdue
+			}
+		})
+	}
+
+	for {
+		logger.Info("Waiting for review", zap.String("action", step.Action), zap.String("assignee", review.Assignee))
+		selector.Select(r.ctx)
+
+		if event == eventOverdue {
+			r.overdue(review)
+			continue
+		}
+		if err := checkReview(*review, data); err != nil {
+			r.reject(step.Action, err)
+			continue
+		}
+		if event == ClaimSignalName {
+			review.Status = ReviewClaimed
+			review.Reviewer = data.Reviewer
+			r.upsertReview(*review)
+			continue
+		}
+
+		review.Reviewer = data.Reviewer
+		review.Notes = data.Notes
+		decided := workflow.Now(r.ctx)
+		review.Decided = &decided
+		if event == RejectSignalName {
+			review.Status = ReviewRejected
+			r.upsertReview(*review)
+			r.state.Steps[i].Status = StatusRejected
+			r.state.Current = r.state.Steps[i]
+			return nil, errApplicantRejected
+		}
+		review.Status = ReviewApproved
+		r.upsertReview(*review)
+		return map[string]interface{}{"decision": "approve", "reviewer": data.Reviewer}, nil
+	}
+}
+
+// overdue marks a review task that passed its due time and reminds the
+// reviewer who claimed it, or its assignee.
+func (r *stepRunner) overdue(review *ReviewTask) {
+	workflow.GetLogger(r.ctx).Info("Review overdue.", zap.String("action", review.Action))
+	review.Overdue = true
+	reviewer := review.Reviewer
+	if reviewer == "" {
+		reviewer = review.Assignee
+	}
+	r.sendReminder(Reminder{
+		WorkflowID: workflow.GetInfo(r.ctx).WorkflowExecution.ID,
+		Action:     review.Action,
+		Reviewer:   reviewer,
+		Time:       workflow.Now(r.ctx),
+	})
+}
+
+// upsertReview records the status, assignee and reviewer of a review task in
+// the search attributes of the execution, when they are turned on.
+func (r *stepRunner) upsertReview(review ReviewTask) {
+	if !r.searchReviews || !r.reviewAttributesOn() {
+		return
+	}
+	err := workflow.UpsertSearchAttributes(r.ctx, map[string]interface{}{
+		ReviewStatusAttribute:   review.Status,
+		ReviewAssigneeAttribute: review.Assignee,
+		ReviewerAttribute:       review.Reviewer,
+	})
+	if err != nil {
+		workflow.GetLogger(r.ctx).Error("Upsert search attributes failed.", zap.Error(err))
+	}
+}
+
+// reviewAttributesOn reports whether review search attributes are turned on.
+// The setting is read once per execution in a side effect, so workers
+// configured differently replay the execution the same way.
+func (r *stepRunner) reviewAttributesOn() bool {
+	if r.reviewAttributes == nil {
+		enabled := false
+		err := workflow.SideEffect(r.ctx, func(ctx workflow.Context) interface{} {
+			return reviewSearchAttributes
+		}).Get(&enabled)
+		if err != nil {
+			workflow.GetLogger(r.ctx).Error("Reading review search attributes setting failed.", zap.Error(err))
+		}
+		r.reviewAttributes = &enabled
+	}
+	return *r.reviewAttributes
+}
+
+// checkReview makes sure a reviewer signal is for the open review task and
+// that a claimed task is decided by the reviewer who claimed it.
+func checkReview(review ReviewTask, data ReviewSignal) error {
+	if data.Reviewer == "" {
+		return errors.New("missing reviewer")
+	}
+	if data.Action != review.Action {
+		return fmt.Errorf("review for %q while waiting on %q", data.Action, review.Action)
+	}
+	if review.Status == ReviewClaimed && review.Reviewer != data.Reviewer {
+		return fmt.Errorf("review claimed by %q", review.Reviewer)
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+)
+
+// This is registration process where you register all your workflows
+// and activity function handlers.
+func init() {
+	activity.Register(evalSOPActivity)
+	activity.Register(evalCETActivity)
+}
+
+// Evaluation outcomes. The outcome of an evaluated step is added to its
+// payload as the "outcome" field, so later steps can branch on it with
+// conditions.
+const (
+	OutcomePass         = "pass"
+	OutcomeReject       = "reject"
+	OutcomeManualReview = "manual-review"
+)
+
+// StatusRejected marks an evaluated step whose score fell below the review
+// threshold. It ends the journey.
+const StatusRejected = "REJECTED"
+
+// errApplicantRejected is returned by runSteps when an evaluation rejected the
+// applicant.
+var errApplicantRejected = errors.New("applicant rejected")
+
+// EvaluationDefinition scores the SOP text and CET answers submitted for a
+// step. Totals of at least Pass pass, totals of at least Review go to manual
+// review and lower totals are rejected.
+type EvaluationDefinition struct {
+	// SOPField and CETField name the payload fields holding the SOP text and
+	// the CET answers object. At least one is required.
+	SOPField string  `json:"sop_field,omitempty"`
+	CETField string  `json:"cet_field,omitempty"`
+	Pass     float64 `json:"pass"`
+	Review   float64 `json:"review"`
+}
+
+// EvaluationInput is the input of the scoring activities.
+type EvaluationInput struct {
+	ApplicantID string            `json:"applicant_id"`
+	Action      string            `json:"action"`
+	SOP         string            `json:"sop,omitempty"`
+	Answers     map[string]string `json:"answers,omitempty"`
+}
+
+// Score is the result of scoring an SOP or CET answers, out of 100.
+type Score struct {
+	Total     float64            `json:"total"`
+	SubScores map[string]float64 `json:"sub_scores,omitempty"`
+}
+
+// Evaluation records the scores of an evaluated step and the outcome they led
+// to.
+type Evaluation struct {
+	Action  string    `json:"action"`
+	SOP     *Score    `json:"sop,omitempty"`
+	CET     *Score    `json:"cet,omitempty"`
+	Total   float64   `json:"total"`
+	Outcome string    `json:"outcome"`
+	Error   string    `json:"error,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// Scorer scores applicant submissions, e.g. with an external grader.
+type Scorer interface {
+	ScoreSOP(ctx context.Context, sop string) (Score, error)
+	ScoreCET(ctx context.Context, answers map[string]string) (Score, error)
+}
+
+// RuleScorer scores locally. An SOP scores on its length against MinWords and
+// MaxWords and on how many Keywords it mentions; CET answers score on how many
+// match AnswerKey, or on how many are answered when there is no key. Question
+// IDs match regardless of case, as viper lowercases the keys of a configured
+// AnswerKey.
+type RuleScorer struct {
+	Keywords  []string
+	MinWords  int
+	MaxWords  int
+	AnswerKey map[string]string
+}
+
+// ScoreSOP scores an SOP, half on length and half on keywords.
+func (s RuleScorer) ScoreSOP(ctx context.Context, sop string) (Score, error) {
+	words := strings.Fields(strings.ToLower(sop))
+	length := 100.0
+	if len(words) < s.MinWords {
+		length = 100 * float64(len(words)) / float64(s.MinWords)
+	} else if s.MaxWords > 0 && len(words) > s.MaxWords {
+		length = 100 * float64(s.MaxWords) / float64(len(words))
+	}
+
+	keywords := 100.0
+	if len(s.Keywords) > 0 {
+		text := strings.Join(words, " ")
+		found := 0
+		for _, keyword := range s.Keywords {
+			if strings.Contains(text, strings.ToLower(keyword)) {
+				found++
+			}
+		}
+		keywords = 100 * float64(found) / float64(len(s.Keywords))
+	}
+
+	return Score{
+		Total:     (length + keywords) / 2,
+		SubScores: map[string]float64{"length": length, "keywords": keywords},
+	}, nil
+}
+
+// ScoreCET scores CET answers.
+func (s RuleScorer) ScoreCET(ctx context.Context, answers map[string]string) (Score, error) {
+	if len(s.AnswerKey) == 0 {
+		if len(answers) == 0 {
+			return Score{SubScores: map[string]float64{"answered": 0}}, nil
+		}
+		answered := 0
+		for _, answer := range answers {
+			if strings.TrimSpace(answer) != "" {
+				answered++
+			}
+		}
+		total := 100 * float64(answered) / float64(len(answers))
+		return Score{Total: total, SubScores: map[string]float64{"answered": total}}, nil
+	}
+
+	given := make(map[string]string, len(answers))
+	for question, answer := range answers {
+		given[strings.ToLower(question)] = answer
+	}
+	correct := 0
+	for question, expected := range s.AnswerKey {
+		if strings.EqualFold(strings.TrimSpace(given[strings.ToLower(question)]), strings.TrimSpace(expected)) {
+			correct++
+		}
+	}
+	total := 100 * float64(correct) / float64(len(s.AnswerKey))
+	return Score{Total: total, SubScores: map[string]float64{"correct": total}}, nil
+}
+
+// scorer is a RuleScorer with a general teaching rubric until SetScorer is
+// called.
+var scorer Scorer = RuleScorer{
+	Keywords: []string{"teach", "student", "learn", "math", "experience"},
+	MinWords: 150,
+	MaxWords: 800,
+}
+
+// SetScorer sets the scorer used by the evaluation activities. The worker
+// sets a RuleScorer with the rubric from the config, if it has one.
+func SetScorer(s Scorer) {
+	scorer = s
+}
+
+func evalSOPActivity(ctx context.Context, input EvaluationInput) (Score, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("SOP evaluation activity started")
+	score, err := scorer.ScoreSOP(ctx, input.SOP)
+	if err != nil {
+		logger.Error("SOP evaluation failed.", zap.Error(err))
+		return score, err
+	}
+	logger.Info("SOP evaluation activity ended", zap.Float64("total", score.Total))
+	return score, nil
+}
+
+func evalCETActivity(ctx context.Context, input EvaluationInput) (Score, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("CET evaluation activity started")
+	score, err := scorer.ScoreCET(ctx, input.Answers)
+	if err != nil {
+		logger.Error("CET evaluation failed.", zap.Error(err))
+		return score, err
+	}
+	logger.Info("CET evaluation activity ended", zap.Float64("total", score.Total))
+	return score, nil
+}
+
+// validateEvaluation checks the evaluation settings of a step.
+func validateEvaluation(step StepDefinition) error {
+	eval := step.Evaluate
+	if eval == nil {
+		return nil
+	}
+	if eval.SOPField == "" && eval.CETField == "" {
+		return errors.New("evaluate needs sopField or cetField")
+	}
+	if eval.Review < 0 || eval.Review > eval.Pass || eval.Pass > 100 {
+		return fmt.Errorf("evaluate thresholds must satisfy 0 <= review (%v) <= pass (%v) <= 100", eval.Review, eval.Pass)
+	}
+	return nil
+}
+
+// evaluate scores the payload submitted for a step and records the
+// evaluation. If scoring fails the applicant goes to manual review.
+func (r *stepRunner) evaluate(step StepDefinition, payload interface{}) Evaluation {
+	eval := step.Evaluate
+	fields, _ := payload.(map[string]interface{})
+	input := EvaluationInput{ApplicantID: r.applicantID, Action: step.Action}
+	evaluation := Evaluation{Action: step.Action, Time: workflow.Now(r.ctx)}
+
+	var err error
+	var totals []float64
+	if eval.SOPField != "" {
+		input.SOP, _ = fields[eval.SOPField].(string)
+		score := Score{}
+		err = workflow.ExecuteActivity(withActivityProfile(r.ctx, "eval-sop"), evalSOPActivity, input).Get(r.ctx, &score)
+		evaluation.SOP = &score
+		totals = append(totals, score.Total)
+	}
+	if err == nil && eval.CETField != "" {
+		input.Answers = toAnswers(fields[eval.CETField])
+		score := Score{}
+		err = workflow.ExecuteActivity(withActivityProfile(r.ctx, "eval-cet"), evalCETActivity, input).Get(r.ctx, &score)
+		evaluation.CET = &score
+		totals = append(totals, score.Total)
+	}
+
+	if err != nil {
+		workflow.GetLogger(r.ctx).Error("Evaluation failed.", zap.String("action", step.Action), zap.Error(err))
+		evaluation.Error = err.Error()
+		evaluation.Outcome = OutcomeManualReview
+	} else {
+		for _, total := range totals {
+			evaluation.Total += total / float64(len(totals))
+		}
+		evaluation.Outcome = eval.outcome(evaluation.Total)
+	}
+	r.state.Evaluations = append(r.state.Evaluations, evaluation)
+	return evaluation
+}
+
+func (eval EvaluationDefinition) outcome(total float64) string {
+	switch {
+	case total >= eval.Pass:
+		return OutcomePass
+	case total >= eval.Review:
+		return OutcomeManualReview
+	default:
+		return OutcomeReject
+	}
+}
+
+// toAnswers converts a CET answers object from a payload into question to
+// answer pairs.
+func toAnswers(value interface{}) map[string]string {
+	fields, _ := value.(map[string]interface{})
+	answers := make(map[string]string, len(fields))
+	for question, answer := range fields {
+		answers[question] = fmt.Sprint(answer)
+	}
+	return answers
+}
+
+// withOutcome returns a copy of a payload object with the evaluation outcome
+// added.
+func withOutcome(payload interface{}, outcome string) map[string]interface{} {
+	fields, _ := payload.(map[string]interface{})
+	result := make(map[string]interface{}, len(fields)+1)
+	for name, value := range fields {
+		result[name] = value
+	}
+	result["outcome"] = outcome
+	return result
+}
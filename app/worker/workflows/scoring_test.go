@@ -0,0 +1,68 @@
+package workflows
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleScorerScoresSOP(t *testing.T) {
+	scorer := RuleScorer{Keywords: []string{"Teach", "student", "math", "experience"}, MinWords: 10, MaxWords: 20}
+	tests := []struct {
+		name      string
+		sop       string
+		length    float64
+		keywords  float64
+		wantTotal float64
+	}{
+		{"within length", "I love to TEACH every student and share my math passion daily", 100, 75, 87.5},
+		{"too short", "I teach math", 30, 50, 40},
+		{"too long", strings.Repeat("student ", 40), 50, 25, 37.5},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			score, err := scorer.ScoreSOP(context.Background(), test.sop)
+
+			require.NoError(t, err)
+			require.Equal(t, map[string]float64{"length": test.length, "keywords": test.keywords}, score.SubScores)
+			require.Equal(t, test.wantTotal, score.Total)
+		})
+	}
+}
+
+func TestRuleScorerMatchesAnswerKeyRegardlessOfCase(t *testing.T) {
+	// Viper lowercases the question IDs of a configured answer key.
+	scorer := RuleScorer{AnswerKey: map[string]string{"q1": "B", "q2": "12", "Q3": "Pythagoras", "q4": "c"}}
+
+	score, err := scorer.ScoreCET(context.Background(), map[string]string{
+		"Q1": "b",
+		"q2": " 12 ",
+		"q3": "pythagoras",
+		"q4": "a",
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, 75.0, score.Total)
+	require.Equal(t, map[string]float64{"correct": 75}, score.SubScores)
+}
+
+func TestRuleScorerCountsAnswersWithoutKey(t *testing.T) {
+	score, err := RuleScorer{}.ScoreCET(context.Background(), map[string]string{"q1": "b", "q2": " ", "q3": "c", "q4": ""})
+	require.NoError(t, err)
+	require.Equal(t, 50.0, score.Total)
+
+	score, err = RuleScorer{}.ScoreCET(context.Background(), nil)
+	require.NoError(t, err)
+	require.Zero(t, score.Total)
+}
+
+func TestEvaluationOutcome(t *testing.T) {
+	eval := EvaluationDefinition{Pass: 70, Review: 40}
+
+	require.Equal(t, OutcomePass, eval.outcome(70))
+	require.Equal(t, OutcomeManualReview, eval.outcome(69.9))
+	require.Equal(t, OutcomeManualReview, eval.outcome(40))
+	require.Equal(t, OutcomeReject, eval.outcome(39.9))
+}
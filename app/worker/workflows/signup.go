@@ -45,7 +45,15 @@ func SignupWorkflow(ctx workflow.Context) (string, error) {
 	}
 	
 	var activityResult string
-	err = workflow.ExecuteActivity(ctx, templateActivity, "Signup").Get(ctx, &activityResult)
+	// Executions started before the step runner schedule the activity with
+	// the arguments they started with.
+	var activityFuture workflow.Future
+	if onStepRunner(ctx) {
+		activityFuture = workflow.ExecuteActivity(withActivityProfile(ctx, "template"), templateActivity, StepInput{WorkflowID: workflowID, RunID: runID, Action: "signup"})
+	} else {
+		activityFuture = workflow.ExecuteActivity(ctx, legacyTemplateActivity, "Signup")
+	}
+	err = activityFuture.Get(ctx, &activityResult)
 	if err != nil {
 		logger.Error("Signup Activity failed.", zap.Error(err))
 		return "", err
@@ -57,6 +65,7 @@ func SignupWorkflow(ctx workflow.Context) (string, error) {
 	signalChan := workflow.GetSignalChannel(ctx, signalName)
 	selector.AddReceive(signalChan, func(c workflow.Channel, more bool) {
 		c.Receive(ctx, &data)
+		workflowState.Sequence++
 		workflow.GetLogger(ctx).Info("Received the signal!", zap.String("signal", signalName))
 	})
 	workflow.GetLogger(ctx).Info("Waiting for signal on channel.. " + signalName)
@@ -0,0 +1,562 @@
+package workflows
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+)
+
+// This is registration process where you register all your workflows
+// and activity function handlers.
+func init() {
+	workflow.Register(StepRunnerWorkflow)
+}
+
+// Step statuses used in WorkflowStep.Status.
+const (
+	StatusNotStarted = "NOT_STARTED"
+	StatusInProgress = "IN_PROGRESS"
+	StatusCompleted  = "COMPLETED"
+)
+
+// StepDefinition describes one screen of a journey: the activity that prepares
+// it, the signal that completes it and the backend call made with the payload.
+type StepDefinition struct {
+	Action   string        `json:"action"`
+	Activity string        `json:"activity,omitempty"`
+	Signal   string        `json:"signal,omitempty"`
+	Backend  string        `json:"backend,omitempty"`
+	Payload  []FieldSchema `json:"payload,omitempty"`
+	When     []Condition   `json:"when,omitempty"`
+
+	// RemindAfter and ExpireAfter are optional deadlines counted from the
+	// moment the step starts waiting. OnExpire is "close" (the default) or
+	// "dormant".
+	RemindAfter time.Duration `json:"remind_after,omitempty"`
+	ExpireAfter time.Duration `json:"expire_after,omitempty"`
+	OnExpire    string        `json:"on_expire,omitempty"`
+
+	// Evaluate scores the submitted payload and branches on the outcome.
+	Evaluate *EvaluationDefinition `json:"evaluate,omitempty"`
+	// Review makes the step wait for a reviewer instead of the applicant.
+	Review *ReviewDefinition `json:"review,omitempty"`
+	// Upload makes the step wait for the applicant to upload a video.
+	Upload *UploadDefinition `json:"upload,omitempty"`
+	// Documents makes the step wait until the applicant's documents are
+	// verified.
+	Documents []DocumentDefinition `json:"documents,omitempty"`
+	// Agreement makes the step an e-sign step for an agreement.
+	Agreement *AgreementDefinition `json:"agreement,omitempty"`
+	// Availability makes the step collect a weekly availability grid.
+	Availability *AvailabilityDefinition `json:"availability,omitempty"`
+}
+
+// StepInput is the argument every step activity receives.
+type StepInput struct {
+	ApplicantID string `json:"applicant_id"`
+	WorkflowID  string `json:"workflow_id"`
+	RunID       string `json:"run_id"`
+	Action      string `json:"action"`
+}
+
+// StepRunnerInput is the input of StepRunnerWorkflow.
+type StepRunnerInput struct {
+	ApplicantID string           `json:"applicant_id"`
+	Steps       []StepDefinition `json:"steps"`
+}
+
+// stepActivities maps the activity names used in step definitions to the
+// registered activity functions.
+var stepActivities = map[string]interface{}{
+	"template":            templateActivity,
+	"degree-details":      degreeDetailsActivity,
+	"stream-selection":    streamSelectionActivity,
+	"grade":               gradeActivity,
+	"watch-video":         watchVideoActivity,
+	"cet-and-sop":         teacherCETAndSOPActivity,
+	"upload-lesson-video": uploadLessonVideoActivity,
+	"submit-documents":    submitDocumentsActivity,
+	"orientation":         orientationActivity,
+	"basic-details":       basicDetailsActivity,
+	"agreement":           agreementActivity,
+	"profile":             profileActivity,
+	"availability":        availabilityActivity,
+}
+
+// validateSteps makes sure every step references a known activity and backend call.
+func validateSteps(steps []StepDefinition) error {
+	if len(steps) == 0 {
+		return errors.New("no steps defined")
+	}
+	for _, step := range steps {
+		if step.Action == "" {
+			return errors.New("step without action")
+		}
+		if _, ok := stepActivities[step.Activity]; step.Activity != "" && !ok {
+			return fmt.Errorf("step %q: unknown activity %q", step.Action, step.Activity)
+		}
+		if _, ok := backendCalls[step.Backend]; step.Backend != "" && !ok {
+			return fmt.Errorf("step %q: unknown backend call %q", step.Action, step.Backend)
+		}
+		if err := validateSchema(step.Payload); err != nil {
+			return fmt.Errorf("step %q: %w", step.Action, err)
+		}
+		if err := validateEvaluation(step); err != nil {
+			return fmt.Errorf("step %q: %w", step.Action, err)
+		}
+		if err := validateReview(step); err != nil {
+			return fmt.Errorf("step %q: %w", step.Action, err)
+		}
+		if err := validateUpload(step); err != nil {
+			return fmt.Errorf("step %q: %w", step.Action, err)
+		}
+		if err := validateDocuments(step); err != nil {
+			return fmt.Errorf("step %q: %w", step.Action, err)
+		}
+		if err := validateAgreement(step); err != nil {
+			return fmt.Errorf("step %q: %w", step.Action, err)
+		}
+		if err := validateAvailability(step); err != nil {
+			return fmt.Errorf("step %q: %w", step.Action, err)
+		}
+		if err := validateDeadlines(step); err != nil {
+			return fmt.Errorf("step %q: %w", step.Action, err)
+		}
+	}
+	return nil
+}
+
+// newWorkflowState builds the initial state for the given steps with the
+// first one in progress.
+func newWorkflowState(steps []StepDefinition) WorkflowState {
+	workflowState := WorkflowState{}
+	for i, step := range steps {
+		workflowState.Steps = append(workflowState.Steps, WorkflowStep{
+			Action: step.Action,
+			Index:  i + 1,
+			Status: StatusNotStarted,
+		})
+	}
+	if len(workflowState.Steps) > 0 {
+		workflowState.Steps[0].Status = StatusInProgress
+		workflowState.Current = workflowState.Steps[0]
+	}
+	return workflowState
+}
+
+// advance completes the current step and moves on to the next one. On the
+// last step Current is left pointing at the completed step.
+func advance(workflowState *WorkflowState) {
+	moveOn(workflowState, StatusCompleted)
+}
+
+// moveOn closes the current step with the given status and moves on to the
+// next one.
+func moveOn(workflowState *WorkflowState, status string) {
+	index := workflowState.Current.Index
+	workflowState.Steps[index-1].Status = status
+	if index < len(workflowState.Steps) {
+		workflowState.Steps[index].Status = StatusInProgress
+		workflowState.Current = workflowState.Steps[index]
+		return
+	}
+	workflowState.Current = workflowState.Steps[index-1]
+}
+
+// checkSubmission returns why a submission cannot complete step, or nil when
+// it can. Submissions must name the step they answer; executions started
+// before actions were required also accept submissions without one.
+// Availability is checked by normalizing it, see normalizeAvailability.
+func checkSubmission(step StepDefinition, workflowState *WorkflowState, data Mystruct, requireAction bool) error {
+	if data.Action == "" && requireAction {
+		return errors.New("submission without action")
+	}
+	if data.Action != "" && data.Action != step.Action {
+		for _, done := range workflowState.Steps {
+			if done.Action == data.Action && done.Status == StatusCompleted {
+				return fmt.Errorf("duplicate submission for %q", data.Action)
+			}
+		}
+		return fmt.Errorf("submission for %q while waiting on %q", data.Action, step.Action)
+	}
+	if err := ValidatePayload(step.Payload, data.Payload); err != nil {
+		return err
+	}
+	if step.Agreement != nil {
+		return checkAcceptance(openAgreement(workflowState, step.Action), data)
+	}
+	return nil
+}
+
+// Rewind records a go-back from one step to an earlier one.
+type Rewind struct {
+	From string    `json:"from"`
+	To   string    `json:"to"`
+	Time time.Time `json:"time"`
+}
+
+// rewind moves workflowState back to the completed step at index, resetting
+// every step after it, and records the rewind.
+func rewind(ctx workflow.Context, workflowState *WorkflowState, index int) {
+	from := workflowState.Current.Action
+	for i := index; i < len(workflowState.Steps); i++ {
+		workflowState.Steps[i].Status = StatusNotStarted
+	}
+	workflowState.Steps[index].Status = StatusInProgress
+	workflowState.Current = workflowState.Steps[index]
+	workflowState.Rewinds = append(workflowState.Rewinds, Rewind{
+		From: from,
+		To:   workflowState.Current.Action,
+		Time: workflow.Now(ctx),
+	})
+}
+
+// rewindTarget returns the index of the completed step a go-back signal asks
+// to return to.
+func rewindTarget(workflowState *WorkflowState, current int, action string) (int, error) {
+	for i := 0; i < current; i++ {
+		if workflowState.Steps[i].Action == action {
+			if workflowState.Steps[i].Status != StatusCompleted {
+				return 0, fmt.Errorf("cannot go back to %q: step is %s", action, workflowState.Steps[i].Status)
+			}
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("cannot go back to %q: not an earlier step", action)
+}
+
+// stepRunner drives a WorkflowState through a list of step definitions.
+type stepRunner struct {
+	ctx           workflow.Context
+	applicantID   string
+	steps         []StepDefinition
+	state         *WorkflowState
+	payloads      map[string]interface{}
+	requireAction bool
+	searchReviews bool
+	// reviewAttributes caches the recorded review search attributes setting.
+	reviewAttributes *bool
+}
+
+// runSteps drives workflowState through the given steps: for each step it runs
+// the step activity, waits for a valid submission for that step, advances the
+// state and makes the backend call with the received payload. Submissions for
+// another step or failing the step schema are recorded in
+// workflowState.Rejections. A go-back signal naming an earlier completed step
+// rewinds the state to it and walks forward again from there. Steps whose
+// conditions do not hold for the payloads accepted so far are skipped;
+// accepted payloads are added to payloads by step action.
+//
+// Review steps wait for a reviewer decision instead, and the open review is
+// exposed through the "review" query and the review search attributes. Upload
+// steps wait for an uploaded video, and the open upload is exposed through the
+// "upload" query. Documents steps wait until the required documents are
+// verified, and their documents are exposed through the "documents" query.
+func runSteps(ctx workflow.Context, applicantID string, steps []StepDefinition, workflowState *WorkflowState, payloads map[string]interface{}) error {
+	r := &stepRunner{
+		ctx:           ctx,
+		applicantID:   applicantID,
+		steps:         steps,
+		state:         workflowState,
+		payloads:      payloads,
+		requireAction: workflow.GetVersion(ctx, "step-aware-submit", workflow.DefaultVersion, 1) == 1,
+		searchReviews: workflow.GetVersion(ctx, "review-search-attributes", workflow.DefaultVersion, 1) == 1,
+	}
+
+	err := workflow.SetQueryHandler(ctx, "review", func(input []byte) (*ReviewTask, error) {
+		return openReview(workflowState), nil
+	})
+	if err != nil {
+		workflow.GetLogger(ctx).Info("SetQueryHandler failed: " + err.Error())
+	}
+	err = workflow.SetQueryHandler(ctx, "upload", func(input []byte) (*UploadTask, error) {
+		return openUpload(workflowState), nil
+	})
+	if err != nil {
+		workflow.GetLogger(ctx).Info("SetQueryHandler failed: " + err.Error())
+	}
+	err = workflow.SetQueryHandler(ctx, "documents", func(input []byte) ([]DocumentStatus, error) {
+		return openDocuments(workflowState), nil
+	})
+	if err != nil {
+		workflow.GetLogger(ctx).Info("SetQueryHandler failed: " + err.Error())
+	}
+	return r.run()
+}
+
+func (r *stepRunner) run() error {
+	logger := workflow.GetLogger(r.ctx)
+
+	for i := r.state.Current.Index - 1; i < len(r.steps); {
+		step := r.steps[i]
+
+		if !shouldRun(step, r.payloads) {
+			logger.Info("Skipping step.", zap.String("action", step.Action))
+			skip(r.state)
+			i++
+			continue
+		}
+
+		if err := r.runActivity(step); err != nil {
+			return err
+		}
+
+		if step.Review != nil {
+			decision, err := r.waitForReview(i)
+			if err != nil {
+				return err
+			}
+			r.payloads[step.Action] = decision
+			advance(r.state)
+			i++
+			continue
+		}
+
+		if step.Upload != nil {
+			r.payloads[step.Action] = r.waitForUpload(i)
+			advance(r.state)
+			i++
+			continue
+		}
+
+		if len(step.Documents) > 0 {
+			documents, back := r.waitForDocuments(i)
+			if back >= 0 {
+				r.forget(back)
+				i = back
+				continue
+			}
+			r.payloads[step.Action] = documents
+			advance(r.state)
+			if step.Backend != "" {
+				data := Mystruct{ApplicantId: r.applicantID, Action: step.Action, Payload: documents}
+				logger.Info(persistProfile(r.ctx, r.state, step.Action, step.Backend, data))
+			}
+			i++
+			continue
+		}
+
+		if step.Agreement != nil {
+			if err := r.presentAgreement(i); err != nil {
+				return err
+			}
+		}
+
+		data, back, err := r.waitForSubmission(i)
+		if err != nil {
+			return err
+		}
+		if back >= 0 {
+			r.forget(back)
+			i = back
+			continue
+		}
+		if step.Evaluate != nil {
+			evaluation := r.evaluate(step, data.Payload)
+			if evaluation.Outcome == OutcomeReject {
+				r.state.Steps[i].Status = StatusRejected
+				r.state.Current = r.state.Steps[i]
+				r.state.Sequence++
+				return errApplicantRejected
+			}
+			data.Payload = withOutcome(data.Payload, evaluation.Outcome)
+		}
+		if step.Agreement != nil {
+			if err := r.signAgreement(i, data); err != nil {
+				return err
+			}
+		}
+		if step.Availability != nil {
+			// waitForSubmission replaced the payload with the schedule.
+			setSchedule(r.state, data.Payload.(Schedule))
+		}
+		r.payloads[step.Action] = data.Payload
+		advance(r.state)
+
+		if step.Backend != "" {
+			logger.Info(persistProfile(r.ctx, r.state, step.Action, step.Backend, data))
+		}
+		// The submission is applied once the step is completed and saved.
+		r.state.Sequence++
+		i++
+	}
+	return nil
+}
+
+// forget drops the payloads of the steps from index back on after a go-back
+// rewound the state to it.
+func (r *stepRunner) forget(back int) {
+	for _, reset := range r.steps[back:] {
+		delete(r.payloads, reset.Action)
+	}
+}
+
+// runActivity runs the activity that prepares a step, if it has one.
+func (r *stepRunner) runActivity(step StepDefinition) error {
+	if step.Activity == "" {
+		return nil
+	}
+	info := workflow.GetInfo(r.ctx)
+	input := StepInput{
+		ApplicantID: r.applicantID,
+		WorkflowID:  info.WorkflowExecution.ID,
+		RunID:       info.WorkflowExecution.RunID,
+		Action:      step.Action,
+	}
+	var activityResult string
+	err := workflow.ExecuteActivity(withActivityProfile(r.ctx, step.Activity), stepActivities[step.Activity], input).Get(r.ctx, &activityResult)
+	if err != nil {
+		workflow.GetLogger(r.ctx).Error("Step activity failed.", zap.String("action", step.Action), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Events waitForSubmission reacts to.
+const (
+	eventSubmit     = "submit"
+	eventGoBack     = "go-back"
+	eventReactivate = "reactivate"
+	eventRemind     = "remind"
+	eventExpire     = "expire"
+)
+
+// waitForSubmission blocks until step i receives a valid submission and
+// returns it, or until a valid go-back signal rewinds the state, in which case
+// the index of the step to resume from is returned instead of -1. When the
+// step expires and closes the journey errJourneyExpired is returned.
+func (r *stepRunner) waitForSubmission(i int) (Mystruct, int, error) {
+	logger := workflow.GetLogger(r.ctx)
+	step := r.steps[i]
+
+	signalName := step.Signal
+	if signalName == "" {
+		signalName = SignalName
+	}
+	var data Mystruct
+	var back Mystruct
+	var ignored Mystruct
+	event := ""
+	selector := workflow.NewSelector(r.ctx)
+	selector.AddReceive(workflow.GetSignalChannel(r.ctx, signalName), func(c workflow.Channel, more bool) {
+		// Decode into an empty struct so fields left out of a submission do
+		// not carry over from the previous one.
+		data = Mystruct{}
+		c.Receive(r.ctx, &data)
+		event = eventSubmit
+		workflow.GetLogger(r.ctx).Info("Received the signal!", zap.String("signal", signalName), zap.String("action", step.Action))
+	})
+	selector.AddReceive(workflow.GetSignalChannel(r.ctx, GoBackSignalName), func(c workflow.Channel, more bool) {
+		c.Receive(r.ctx, &back)
+		event = eventGoBack
+		workflow.GetLogger(r.ctx).Info("Received the signal!", zap.String("signal", GoBackSignalName), zap.String("action", back.Action))
+	})
+	selector.AddReceive(workflow.GetSignalChannel(r.ctx, ReactivateSignalName), func(c workflow.Channel, more bool) {
+		c.Receive(r.ctx, &ignored)
+		event = eventReactivate
+		workflow.GetLogger(r.ctx).Info("Received the signal!", zap.String("signal", ReactivateSignalName))
+	})
+
+	timerCtx, cancelTimers := workflow.WithCancel(r.ctx)
+	defer func() { cancelTimers() }()
+	addDeadlines(timerCtx, selector, step, &event)
+
+	for {
+		logger.Info("Waiting for signal on channel.. "+signalName, zap.String("action", step.Action))
+		selector.Select(r.ctx)
+
+		switch event {
+		case eventRemind:
+			r.remind(step)
+			continue
+		case eventExpire:
+			if !r.expire(i) {
+				return data, -1, errJourneyExpired
+			}
+			continue
+		}
+
+		if r.state.Dormant {
+			r.reactivate(i)
+			cancelTimers()
+			timerCtx, cancelTimers = workflow.WithCancel(r.ctx)
+			addDeadlines(timerCtx, selector, step, &event)
+		}
+
+		switch event {
+		case eventGoBack:
+			target, err := rewindTarget(r.state, i, back.Action)
+			if err != nil {
+				r.reject(back.Action, err)
+				continue
+			}
+			rewind(r.ctx, r.state, target)
+			return back, target, nil
+		case eventSubmit:
+			logger.Info("payload", zap.Any("data", data))
+			err := checkSubmission(step, r.state, data, r.requireAction)
+			if err == nil && step.Availability != nil {
+				data.Payload, err = r.normalizeAvailability(step, data.Payload)
+			}
+			if err == nil {
+				return data, -1, nil
+			}
+			r.reject(step.Action, err)
+			r.state.Sequence++
+		}
+	}
+}
+
+// reject records a submission or go-back signal the runner refused.
+func (r *stepRunner) reject(action string, err error) {
+	workflow.GetLogger(r.ctx).Info("Rejected signal.", zap.String("action", action), zap.Error(err))
+	r.state.Rejections = append(r.state.Rejections, Rejection{
+		Action: action,
+		Reason: err.Error(),
+		Time:   workflow.Now(r.ctx),
+	})
+}
+
+// StepRunnerWorkflow runs an arbitrary ordered list of steps and exposes the
+// resulting WorkflowState through the "state" query.
+func StepRunnerWorkflow(ctx workflow.Context, input StepRunnerInput) (string, error) {
+	ctx = workflow.WithActivityOptions(ctx, activityOptions)
+
+	logger := workflow.GetLogger(ctx)
+	logger.Info("Step runner workflow started")
+	logger.Info("Applicant ID: " + input.ApplicantID)
+
+	err := validateSteps(input.Steps)
+	if err == nil {
+		err = validateConditions(input.Steps)
+	}
+	if err != nil {
+		logger.Error("Invalid step definitions.", zap.Error(err))
+		return "", err
+	}
+
+	workflowState := newWorkflowState(input.Steps)
+
+	err = workflow.SetQueryHandler(ctx, "state", func(input []byte) (WorkflowState, error) {
+		return workflowState, nil
+	})
+	if err != nil {
+		logger.Info("SetQueryHandler failed: " + err.Error())
+	}
+
+	err = runSteps(ctx, input.ApplicantID, input.Steps, &workflowState, map[string]interface{}{})
+	if errors.Is(err, errJourneyExpired) {
+		return "Step runner expired", nil
+	}
+	if errors.Is(err, errApplicantRejected) {
+		return "Step runner rejected", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return "Step runner completed", nil
+}
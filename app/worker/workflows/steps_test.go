@@ -0,0 +1,320 @@
+package workflows
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/cadence/testsuite"
+	"go.uber.org/cadence/workflow"
+)
+
+type StepsTestSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+
+	env *testsuite.TestWorkflowEnvironment
+	at  time.Duration
+}
+
+func TestStepsTestSuite(t *testing.T) {
+	suite.Run(t, new(StepsTestSuite))
+}
+
+func (s *StepsTestSuite) SetupTest() {
+	s.env = s.NewTestWorkflowEnvironment()
+	s.at = 0
+}
+
+// signal sends a signal a minute after the previous one.
+func (s *StepsTestSuite) signal(name string, arg interface{}) {
+	s.at += time.Minute
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(name, arg)
+	}, s.at)
+}
+
+func (s *StepsTestSuite) submit(action string, payload interface{}) {
+	s.signal(SignalName, Mystruct{ApplicantId: "applicant-1", Action: action, Payload: payload})
+}
+
+// run runs steps to the end and returns the workflow result and final state.
+func (s *StepsTestSuite) run(steps ...StepDefinition) (string, WorkflowState) {
+	s.env.ExecuteWorkflow(StepRunnerWorkflow, StepRunnerInput{ApplicantID: "applicant-1", Steps: steps})
+	s.Require().True(s.env.IsWorkflowCompleted())
+	s.Require().NoError(s.env.GetWorkflowError())
+
+	var result string
+	s.Require().NoError(s.env.GetWorkflowResult(&result))
+	value, err := s.env.QueryWorkflow("state")
+	s.Require().NoError(err)
+	var state WorkflowState
+	s.Require().NoError(value.Get(&state))
+	return result, state
+}
+
+func (s *StepsTestSuite) statuses(state WorkflowState) []string {
+	var statuses []string
+	for _, step := range state.Steps {
+		statuses = append(statuses, step.Status)
+	}
+	return statuses
+}
+
+func (s *StepsTestSuite) Test_Advance() {
+	s.submit("personal-info", map[string]interface{}{"name": "Asha"})
+	s.submit("contact", nil)
+
+	result, state := s.run(
+		StepDefinition{Action: "personal-info", Payload: []FieldSchema{{Name: "name", Type: "string", Required: true}}},
+		StepDefinition{Action: "contact"},
+	)
+
+	s.Equal("Step runner completed", result)
+	s.Equal([]string{StatusCompleted, StatusCompleted}, s.statuses(state))
+	s.Equal("contact", state.Current.Action)
+	s.Empty(state.Rejections)
+}
+
+func (s *StepsTestSuite) Test_RejectsInvalidPayload() {
+	s.submit("first", map[string]interface{}{})
+	s.submit("first", map[string]interface{}{"age": "thirty"})
+	s.submit("first", map[string]interface{}{"age": 30, "name": "Asha"})
+	s.submit("first", map[string]interface{}{"age": 30})
+
+	result, state := s.run(
+		StepDefinition{Action: "first", Payload: []FieldSchema{{Name: "age", Type: "number", Required: true}}},
+	)
+
+	s.Equal("Step runner completed", result)
+	s.Equal([]string{StatusCompleted}, s.statuses(state))
+	s.Require().Len(state.Rejections, 3)
+	s.Equal(`field "age" is required`, state.Rejections[0].Reason)
+	s.Equal("unknown fields: name", state.Rejections[2].Reason)
+}
+
+func (s *StepsTestSuite) Test_RejectsSubmissionsForAnotherStep() {
+	s.submit("second", nil)
+	s.submit("", nil)
+	s.submit("first", nil)
+	s.submit("first", nil)
+	s.submit("second", nil)
+
+	result, state := s.run(
+		StepDefinition{Action: "first"},
+		StepDefinition{Action: "second"},
+	)
+
+	s.Equal("Step runner completed", result)
+	s.Equal([]string{StatusCompleted, StatusCompleted}, s.statuses(state))
+	s.Require().Len(state.Rejections, 3)
+	s.Equal(`submission for "second" while waiting on "first"`, state.Rejections[0].Reason)
+	s.Equal("submission without action", state.Rejections[1].Reason)
+	s.Equal(`duplicate submission for "first"`, state.Rejections[2].Reason)
+}
+
+func (s *StepsTestSuite) Test_GoBack() {
+	s.submit("first", nil)
+	s.submit("second", nil)
+	// Only completed earlier steps can be gone back to.
+	s.signal(GoBackSignalName, Mystruct{Action: "third"})
+	s.signal(GoBackSignalName, Mystruct{Action: "first"})
+	s.submit("first", nil)
+	s.submit("second", nil)
+	s.submit("third", nil)
+
+	result, state := s.run(
+		StepDefinition{Action: "first"},
+		StepDefinition{Action: "second"},
+		StepDefinition{Action: "third"},
+	)
+
+	s.Equal("Step runner completed", result)
+	s.Equal([]string{StatusCompleted, StatusCompleted, StatusCompleted}, s.statuses(state))
+	s.Require().Len(state.Rewinds, 1)
+	s.Equal("third", state.Rewinds[0].From)
+	s.Equal("first", state.Rewinds[0].To)
+	s.Require().Len(state.Rejections, 1)
+	s.Equal("third", state.Rejections[0].Action)
+}
+
+func (s *StepsTestSuite) Test_SkipsStepsWhoseConditionsDoNotHold() {
+	s.submit("role", map[string]interface{}{"role": "tutor"})
+	s.submit("done", nil)
+
+	result, state := s.run(
+		StepDefinition{Action: "role", Payload: []FieldSchema{{Name: "role", Type: "string", Enum: []string{"teacher", "tutor"}}}},
+		StepDefinition{Action: "experience", When: []Condition{{Step: "role", Field: "role", Equals: "teacher"}}},
+		StepDefinition{Action: "done"},
+	)
+
+	s.Equal("Step runner completed", result)
+	s.Equal([]string{StatusCompleted, StatusSkipped, StatusCompleted}, s.statuses(state))
+}
+
+func (s *StepsTestSuite) Test_ExpireClosesJourney() {
+	result, state := s.run(
+		StepDefinition{Action: "first", RemindAfter: time.Hour, ExpireAfter: 2 * time.Hour},
+		StepDefinition{Action: "second"},
+	)
+
+	s.Equal("Step runner expired", result)
+	s.Equal([]string{StatusExpired, StatusNotStarted}, s.statuses(state))
+	s.Require().Len(state.Reminders, 1)
+	s.Equal("first", state.Reminders[0].Action)
+}
+
+func (s *StepsTestSuite) Test_ExpireDormantReactivates() {
+	s.env.RegisterDelayedCallback(func() {
+		value, err := s.env.QueryWorkflow("state")
+		s.Require().NoError(err)
+		var state WorkflowState
+		s.Require().NoError(value.Get(&state))
+		s.True(state.Dormant)
+		s.Equal(StatusExpired, state.Current.Status)
+
+		s.env.SignalWorkflow(SignalName, Mystruct{Action: "first"})
+	}, 2*time.Hour)
+
+	result, state := s.run(
+		StepDefinition{Action: "first", ExpireAfter: time.Hour, OnExpire: "dormant"},
+	)
+
+	s.Equal("Step runner completed", result)
+	s.False(state.Dormant)
+	s.Equal([]string{StatusCompleted}, s.statuses(state))
+}
+
+func (s *StepsTestSuite) Test_ReviewRejectionClosesJourney() {
+	s.signal(ClaimSignalName, ReviewSignal{Action: "interview", Reviewer: "reviewer-1"})
+	// A claimed review is only decided by the reviewer who claimed it.
+	s.signal(ApproveSignalName, ReviewSignal{Action: "interview", Reviewer: "reviewer-2"})
+	s.signal(RejectSignalName, ReviewSignal{Action: "interview", Reviewer: "reviewer-1", Notes: "not a fit"})
+
+	result, state := s.run(
+		StepDefinition{Action: "interview", Review: &ReviewDefinition{Assignee: "interviewers"}},
+		StepDefinition{Action: "welcome"},
+	)
+
+	s.Equal("Step runner rejected", result)
+	s.Equal([]string{StatusRejected, StatusNotStarted}, s.statuses(state))
+	s.Require().Len(state.Reviews, 1)
+	s.Equal(ReviewRejected, state.Reviews[0].Status)
+	s.Equal("reviewer-1", state.Reviews[0].Reviewer)
+	s.Len(state.Rejections, 1)
+}
+
+// reviewAttributes runs steps and returns the review search attributes the
+// execution ended with.
+func (s *StepsTestSuite) reviewAttributes(steps ...StepDefinition) map[string]string {
+	s.env.RegisterWorkflow(searchAttributesWorkflow)
+	s.env.ExecuteWorkflow(searchAttributesWorkflow, StepRunnerInput{ApplicantID: "applicant-1", Steps: steps})
+	s.Require().True(s.env.IsWorkflowCompleted())
+	s.Require().NoError(s.env.GetWorkflowError())
+
+	var attributes map[string]string
+	s.Require().NoError(s.env.GetWorkflowResult(&attributes))
+	return attributes
+}
+
+// searchAttributesWorkflow runs the step runner and returns the review search
+// attributes of the execution.
+func searchAttributesWorkflow(ctx workflow.Context, input StepRunnerInput) (map[string]string, error) {
+	if _, err := StepRunnerWorkflow(ctx, input); err != nil {
+		return nil, err
+	}
+	attributes := map[string]string{}
+	if searchAttributes := workflow.GetInfo(ctx).SearchAttributes; searchAttributes != nil {
+		for _, key := range []string{ReviewStatusAttribute, ReviewAssigneeAttribute, ReviewerAttribute} {
+			var value string
+			if field, ok := searchAttributes.IndexedFields[key]; ok && json.Unmarshal(field, &value) == nil {
+				attributes[key] = value
+			}
+		}
+	}
+	return attributes, nil
+}
+
+func (s *StepsTestSuite) Test_ReviewSearchAttributesAreOffByDefault() {
+	s.signal(ApproveSignalName, ReviewSignal{Action: "interview", Reviewer: "reviewer-1"})
+
+	attributes := s.reviewAttributes(StepDefinition{Action: "interview", Review: &ReviewDefinition{Assignee: "interviewers"}})
+
+	s.Empty(attributes)
+}
+
+func (s *StepsTestSuite) Test_ReviewSearchAttributesTrackTheTask() {
+	SetReviewSearchAttributes(true)
+	defer SetReviewSearchAttributes(false)
+	s.signal(ClaimSignalName, ReviewSignal{Action: "interview", Reviewer: "reviewer-1"})
+	s.signal(ApproveSignalName, ReviewSignal{Action: "interview", Reviewer: "reviewer-1"})
+
+	attributes := s.reviewAttributes(StepDefinition{Action: "interview", Review: &ReviewDefinition{Assignee: "interviewers"}})
+
+	s.Equal(map[string]string{
+		ReviewStatusAttribute:   ReviewApproved,
+		ReviewAssigneeAttribute: "interviewers",
+		ReviewerAttribute:       "reviewer-1",
+	}, attributes)
+}
+
+func (s *StepsTestSuite) Test_OverdueReviewRemindsReviewer() {
+	s.signal(ClaimSignalName, ReviewSignal{Action: "interview", Reviewer: "reviewer-1"})
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(ApproveSignalName, ReviewSignal{Action: "interview", Reviewer: "reviewer-1"})
+	}, 3*time.Hour)
+
+	result, state := s.run(StepDefinition{Action: "interview", Review: &ReviewDefinition{Assignee: "interviewers", DueAfter: 2 * time.Hour}})
+
+	s.Equal("Step runner completed", result)
+	s.Require().Len(state.Reviews, 1)
+	s.True(state.Reviews[0].Overdue)
+	s.Equal(ReviewApproved, state.Reviews[0].Status)
+	s.Require().Len(state.Reminders, 1)
+	s.Equal("reviewer-1", state.Reminders[0].Reviewer)
+}
+
+func (s *StepsTestSuite) Test_DocumentsKeepVerifiedAcrossGoBack() {
+	key := func(documentType string) string {
+		return DocumentPrefix("default-test-workflow-id", "documents", documentType) + "scan.pdf"
+	}
+	s.submit("personal-info", nil)
+	s.signal(DocumentUploadedSignalName, DocumentSignal{Action: "documents", Type: "id-proof", Key: key("id-proof")})
+	s.signal(VerifyDocumentSignalName, DocumentSignal{Action: "documents", Type: "id-proof", Reviewer: "reviewer-1"})
+	s.signal(GoBackSignalName, Mystruct{Action: "personal-info"})
+	s.submit("personal-info", nil)
+	// The verified ID proof is kept, so only the degree is left.
+	s.signal(DocumentUploadedSignalName, DocumentSignal{Action: "documents", Type: "degree", Key: key("degree")})
+	s.signal(VerifyDocumentSignalName, DocumentSignal{Action: "documents", Type: "degree", Reviewer: "reviewer-1"})
+
+	result, state := s.run(
+		StepDefinition{Action: "personal-info"},
+		StepDefinition{Action: "documents", Documents: []DocumentDefinition{{Type: "id-proof", Required: true}, {Type: "degree", Required: true}}},
+	)
+
+	s.Equal("Step runner completed", result)
+	s.Equal([]string{StatusCompleted, StatusCompleted}, s.statuses(state))
+	s.Len(state.Rewinds, 1)
+	s.Require().Len(state.Documents, 2)
+	for _, document := range state.Documents {
+		s.Equal(DocumentVerified, document.Status)
+		s.Equal(1, document.Uploads)
+	}
+}
+
+func (s *StepsTestSuite) Test_SequenceCountsProcessedSubmissions() {
+	s.submit("second", nil)
+	s.submit("first", map[string]interface{}{})
+	s.submit("first", map[string]interface{}{"age": 30})
+	s.submit("second", nil)
+
+	_, state := s.run(
+		StepDefinition{Action: "first", Payload: []FieldSchema{{Name: "age", Type: "number", Required: true}}},
+		StepDefinition{Action: "second"},
+	)
+
+	// Rejected submissions are counted too, so waiting submitters see them.
+	s.Len(state.Rejections, 2)
+	s.Equal(4, state.Sequence)
+}
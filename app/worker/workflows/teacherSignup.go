@@ -62,7 +62,9 @@ func createTeacherSignupState() WorkflowState {
 func signupActivity(ctx context.Context) (string, error) {
 	logger := activity.GetLogger(ctx)
 	logger.Info("Agreement activity started")
-	call_api()
+	if err := callAPI(ctx); err != nil {
+		return "", err
+	}
 	logger.Info("Agreement activity ended")
 	return "Agreement activity ended", nil
 }
@@ -88,7 +90,15 @@ func teacherSignupWorkflow(ctx workflow.Context, applicantID string) (string, er
 	}
 
 	var activityResult string
-	err = workflow.ExecuteActivity(ctx, orientationActivity, applicantID, workflowID, runID).Get(ctx, &activityResult)
+	// Executions started before the step runner schedule the activity with
+	// the arguments they started with.
+	var activityFuture workflow.Future
+	if onStepRunner(ctx) {
+		activityFuture = workflow.ExecuteActivity(withActivityProfile(ctx, "orientation"), orientationActivity, StepInput{ApplicantID: applicantID, WorkflowID: workflowID, RunID: runID, Action: "signup"})
+	} else {
+		activityFuture = workflow.ExecuteActivity(ctx, legacyOrientationActivity, applicantID, workflowID, runID)
+	}
+	err = activityFuture.Get(ctx, &activityResult)
 	if err != nil {
 		logger.Error("Degree Details Activity failed.", zap.Error(err))
 		return "", err
@@ -98,12 +108,15 @@ func teacherSignupWorkflow(ctx workflow.Context, applicantID string) (string, er
   	selector := workflow.NewSelector(ctx)
  	var data Mystruct
 	signalChan := workflow.GetSignalChannel(ctx, signalName)
+	// Executions started before the profile was persisted once persist it in
+	// the signal callback as well.
+	persistOnce := workflow.GetVersion(ctx, "persist-once", workflow.DefaultVersion, 1) == 1
 	selector.AddReceive(signalChan, func(c workflow.Channel, more bool) {
 		c.Receive(ctx, &data)
-		var msg1 string
-		msg1, err = call(data)
-		workflowState.Current.Status = msg1
-		//workflowState.Current.Status = "COMPLETED"
+		if !persistOnce {
+			persistProfile(ctx, &workflowState, "signup", "update-profile", data)
+		}
+		workflowState.Current.Status = "COMPLETED"
 		workflowState.Steps[0].Status = "COMPLETED"
 		workflow.GetLogger(ctx).Info("Received the signal!", zap.String("signal", signalName))
 	})
@@ -115,11 +128,13 @@ func teacherSignupWorkflow(ctx workflow.Context, applicantID string) (string, er
 
 	// call BE API
 	var msg string
-	msg, err = call(data)
+	msg = persistProfile(ctx, &workflowState, "signup", "update-profile", data)
 	logger.Info(msg)
+	workflowState.Sequence++
 
 	selector.AddReceive(signalChan, func(c workflow.Channel, more bool) {
 		c.Receive(ctx, &data)
+		workflowState.Sequence++
 		workflow.GetLogger(ctx).Info("Received the signal!", zap.String("signal", signalName))
 	})
 	workflow.GetLogger(ctx).Info("Waiting for signal on channel.. " + signalName)
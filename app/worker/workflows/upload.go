@@ -0,0 +1,511 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/adapters/objectstore"
+
+	"go.uber.org/cadence"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+)
+
+// This is registration process where you register all your workflows
+// and activity function handlers.
+func init() {
+	activity.Register(validateVideoActivity)
+	activity.Register(storeVideoActivity)
+	activity.Register(attachVideoActivity)
+}
+
+// UploadCompleteSignalName is the signal sent once the applicant has uploaded
+// the file of an upload step.
+const UploadCompleteSignalName = "upload-complete"
+
+// ReasonInvalidVideo is the reason validateVideoActivity fails with when the
+// uploaded file is not an acceptable video. It is not retried.
+const ReasonInvalidVideo = "invalid-video"
+
+// Upload statuses used in UploadTask.Status.
+const (
+	UploadWaiting    = "WAITING"
+	UploadProcessing = "PROCESSING"
+	UploadCompleted  = "COMPLETED"
+	UploadFailed     = "FAILED"
+)
+
+// Stages of the upload pipeline, in the order they run.
+const (
+	StageValidate = "validate"
+	StageStore    = "store"
+	StageAttach   = "attach"
+)
+
+// UploadDefinition turns a step into a file upload: the applicant uploads a
+// video to a pre-signed target and the step completes once the video is
+// validated, stored and attached to the applicant profile. Containers lists
+// the accepted containers, e.g. "mp4"; MaxSize is in bytes. Zero values do
+// not limit.
+type UploadDefinition struct {
+	Containers  []string      `json:"containers,omitempty"`
+	MaxSize     int64         `json:"max_size,omitempty"`
+	MaxDuration time.Duration `json:"max_duration,omitempty"`
+}
+
+// uploadActivityOptions are the built-in "upload" activity profile. Probing
+// and copying a large video takes a while; invalid videos are not retried.
+var uploadActivityOptions = workflow.ActivityOptions{
+	ScheduleToStartTimeout: time.Minute * 5,
+	StartToCloseTimeout:    time.Hour,
+	HeartbeatTimeout:       time.Minute,
+	RetryPolicy: &cadence.RetryPolicy{
+		InitialInterval:          time.Second * 10,
+		BackoffCoefficient:       2.0,
+		MaximumInterval:          time.Minute * 5,
+		ExpirationInterval:       time.Hour * 3,
+		NonRetriableErrorReasons: []string{ReasonInvalidVideo, ReasonHTTPClientError},
+	},
+}
+
+// VideoInfo is what a VideoProbe found out about a video. Duration is zero
+// when the probe cannot read it.
+type VideoInfo struct {
+	Container string        `json:"container"`
+	Duration  time.Duration `json:"duration,omitempty"`
+	Size      int64         `json:"size"`
+}
+
+// VideoProbe inspects an uploaded video.
+type VideoProbe interface {
+	Probe(ctx context.Context, r io.Reader) (VideoInfo, error)
+}
+
+// ObjectStore keeps uploaded files by key.
+type ObjectStore interface {
+	// PresignUpload returns a target the applicant can upload a file for key
+	// to.
+	PresignUpload(key string) (objectstore.UploadTarget, error)
+	// Stat returns the size of the object stored under key, or
+	// objectstore.ErrNotFound.
+	Stat(ctx context.Context, key string) (int64, error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Put(ctx context.Context, key string, r io.Reader) error
+}
+
+// objectStore is unset until SetObjectStore is called; activities needing it
+// fail with errNoObjectStore until then.
+var objectStore ObjectStore
+
+var errNoObjectStore = errors.New("no object store set: call SetObjectStore before starting the worker")
+
+// SetObjectStore sets the store the upload pipeline reads uploads from and
+// stores videos in. It must be called before the worker starts.
+func SetObjectStore(s ObjectStore) {
+	objectStore = s
+}
+
+// videoProbe is a HeaderProbe until SetVideoProbe is called.
+var videoProbe VideoProbe = HeaderProbe{}
+
+// SetVideoProbe sets the probe uploaded videos are validated with, e.g. one
+// running ffprobe, which also reads the duration of WebM and Matroska files.
+// It must be called before the worker starts.
+func SetVideoProbe(p VideoProbe) {
+	videoProbe = p
+}
+
+// ProbeError is returned by a VideoProbe that could not inspect a video for
+// reasons other than the video itself, e.g. a missing ffprobe binary. Unlike
+// other probe errors it is retried.
+type ProbeError struct {
+	Err error
+}
+
+func (e *ProbeError) Error() string {
+	return "probe failed: " + e.Err.Error()
+}
+
+func (e *ProbeError) Unwrap() error {
+	return e.Err
+}
+
+// UploadStage records a stage of the upload pipeline.
+type UploadStage struct {
+	Name   string    `json:"name"`
+	Status string    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// UploadTask is an upload requested by an upload step. A failed upload is
+// followed by a new task waiting for the applicant to upload again.
+type UploadTask struct {
+	Action    string        `json:"action"`
+	Status    string        `json:"status"`
+	Created   time.Time     `json:"created"`
+	Key       string        `json:"key,omitempty"`
+	Video     *VideoInfo    `json:"video,omitempty"`
+	Reference string        `json:"reference,omitempty"`
+	Stages    []UploadStage `json:"stages,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// UploadSignal is the payload of the upload-complete signal.
+type UploadSignal struct {
+	WorkflowId string `json:"workflowId"`
+	RunId      string `json:"runId"`
+	Action     string `json:"action"`
+	Key        string `json:"key"`
+}
+
+// UploadInput is the input of the upload pipeline activities.
+type UploadInput struct {
+	ApplicantID string           `json:"applicant_id"`
+	Action      string           `json:"action"`
+	Key         string           `json:"key"`
+	Limits      UploadDefinition `json:"limits"`
+	Video       *VideoInfo       `json:"video,omitempty"`
+	Reference   string           `json:"reference,omitempty"`
+}
+
+// UploadProgress is the heartbeat detail of the upload pipeline activities.
+type UploadProgress struct {
+	Stage string `json:"stage"`
+	Bytes int64  `json:"bytes"`
+	Size  int64  `json:"size"`
+}
+
+// UploadPrefix is the prefix of the keys applicants upload the file of a step
+// to. Uploads for other keys are refused.
+func UploadPrefix(workflowID string, action string) string {
+	return "uploads/" + workflowID + "/" + action + "/"
+}
+
+// storedKey is the key a validated upload is stored under.
+func storedKey(uploadKey string) string {
+	return "lesson-videos/" + strings.TrimPrefix(uploadKey, "uploads/")
+}
+
+// validateUpload checks the upload settings of a step. Upload steps are
+// completed by the upload-complete signal, so they take no payload.
+func validateUpload(step StepDefinition) error {
+	if step.Upload == nil {
+		return nil
+	}
+	if len(step.Payload) > 0 || step.Evaluate != nil || step.Review != nil || step.Signal != "" {
+		return errors.New("upload steps take no payload, evaluate, review or signal")
+	}
+	if step.Upload.MaxSize < 0 || step.Upload.MaxDuration < 0 {
+		return errors.New("negative maxSize or maxDuration")
+	}
+	for _, container := range step.Upload.Containers {
+		if !knownContainers[container] {
+			return fmt.Errorf("unknown container %q", container)
+		}
+	}
+	return nil
+}
+
+// openUpload returns the upload task the journey is waiting on, if any.
+func openUpload(workflowState *WorkflowState) *UploadTask {
+	if n := len(workflowState.Uploads); n > 0 && workflowState.Uploads[n-1].Status == UploadWaiting {
+		return &workflowState.Uploads[n-1]
+	}
+	return nil
+}
+
+// waitForUpload creates an upload task for step i and waits for the
+// upload-complete signal, then validates, stores and attaches the video.
+// Signals that do not fit the task are recorded as rejections; a video that
+// fails validation or cannot be stored is recorded and the applicant can
+// upload again. It returns the stored video as the step payload.
+func (r *stepRunner) waitForUpload(i int) map[string]interface{} {
+	logger := workflow.GetLogger(r.ctx)
+	step := r.steps[i]
+	prefix := UploadPrefix(workflow.GetInfo(r.ctx).WorkflowExecution.ID, step.Action)
+	channel := workflow.GetSignalChannel(r.ctx, UploadCompleteSignalName)
+
+	for {
+		r.state.Uploads = append(r.state.Uploads, UploadTask{
+			Action:  step.Action,
+			Status:  UploadWaiting,
+			Created: workflow.Now(r.ctx),
+		})
+		upload := &r.state.Uploads[len(r.state.Uploads)-1]
+
+		var data UploadSignal
+		for {
+			logger.Info("Waiting for upload", zap.String("action", step.Action))
+			channel.Receive(r.ctx, &data)
+			logger.Info("Received the signal!", zap.String("signal", UploadCompleteSignalName), zap.String("key", data.Key))
+			if err := checkUpload(*upload, prefix, data); err != nil {
+				r.reject(step.Action, err)
+				continue
+			}
+			break
+		}
+
+		upload.Key = data.Key
+		upload.Status = UploadProcessing
+		if err := r.processUpload(upload, step); err != nil {
+			upload.Status = UploadFailed
+			upload.Error = err.Error()
+			r.reject(step.Action, err)
+			continue
+		}
+		upload.Status = UploadCompleted
+		return map[string]interface{}{
+			"reference": upload.Reference,
+			"container": upload.Video.Container,
+			"duration":  upload.Video.Duration.Seconds(),
+			"size":      upload.Video.Size,
+		}
+	}
+}
+
+// processUpload runs the pipeline stages for an upload. A failing attach is
+// recorded like other backend calls and does not hold up the journey.
+func (r *stepRunner) processUpload(upload *UploadTask, step StepDefinition) error {
+	input := UploadInput{
+		ApplicantID: r.applicantID,
+		Action:      step.Action,
+		Key:         upload.Key,
+		Limits:      *step.Upload,
+	}
+
+	var video VideoInfo
+	if err := r.runUploadStage(upload, StageValidate, "validate-video", validateVideoActivity, input, &video); err != nil {
+		return err
+	}
+	upload.Video = &video
+	input.Video = &video
+
+	if err := r.runUploadStage(upload, StageStore, "store-video", storeVideoActivity, input, &upload.Reference); err != nil {
+		return err
+	}
+	input.Reference = upload.Reference
+
+	var status string
+	_ = r.runUploadStage(upload, StageAttach, "attach-video", attachVideoActivity, input, &status)
+	return nil
+}
+
+// runUploadStage runs the activity of a pipeline stage and records its status
+// on the upload.
+func (r *stepRunner) runUploadStage(upload *UploadTask, stage string, activityName string, activityFn interface{}, input UploadInput, result interface{}) error {
+	upload.Stages = append(upload.Stages, UploadStage{Name: stage, Status: StatusInProgress, Time: workflow.Now(r.ctx)})
+	record := &upload.Stages[len(upload.Stages)-1]
+
+	err := workflow.ExecuteActivity(withActivityProfile(r.ctx, activityName), activityFn, input).Get(r.ctx, result)
+	record.Time = workflow.Now(r.ctx)
+	if err != nil {
+		workflow.GetLogger(r.ctx).Error("Upload stage failed.", zap.String("stage", stage), zap.Error(err))
+		record.Status = UploadFailed
+		record.Error = errorMessage(err)
+		return fmt.Errorf("%s: %s", stage, record.Error)
+	}
+	record.Status = StatusCompleted
+	return nil
+}
+
+// errorMessage returns the reason of an activity error together with the
+// message it was created with.
+func errorMessage(err error) string {
+	var customErr *cadence.CustomError
+	var details string
+	if errors.As(err, &customErr) && customErr.HasDetails() && customErr.Details(&details) == nil {
+		return customErr.Reason() + ": " + details
+	}
+	return err.Error()
+}
+
+// checkUpload makes sure an upload-complete signal is for the open upload
+// task and names a key issued for it.
+func checkUpload(upload UploadTask, prefix string, data UploadSignal) error {
+	if data.Action != upload.Action {
+		return fmt.Errorf("upload for %q while waiting on %q", data.Action, upload.Action)
+	}
+	if !strings.HasPrefix(data.Key, prefix) {
+		return fmt.Errorf("upload key %q not issued for %q", data.Key, upload.Action)
+	}
+	return nil
+}
+
+// validateVideoActivity probes an uploaded video and checks it against the
+// limits of its step.
+func validateVideoActivity(ctx context.Context, input UploadInput) (VideoInfo, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Validate video activity started", zap.String("key", input.Key))
+	if objectStore == nil {
+		return VideoInfo{}, errNoObjectStore
+	}
+
+	size, err := objectStore.Stat(ctx, input.Key)
+	if errors.Is(err, objectstore.ErrNotFound) {
+		return VideoInfo{}, cadence.NewCustomError(ReasonInvalidVideo, "no file uploaded")
+	}
+	if err != nil {
+		return VideoInfo{}, err
+	}
+	if input.Limits.MaxSize > 0 && size > input.Limits.MaxSize {
+		return VideoInfo{}, cadence.NewCustomError(ReasonInvalidVideo, fmt.Sprintf("file of %d bytes exceeds %d", size, input.Limits.MaxSize))
+	}
+
+	file, err := objectStore.Open(ctx, input.Key)
+	if err != nil {
+		return VideoInfo{}, err
+	}
+	defer file.Close()
+
+	reader := newProgressReader(ctx, file)
+	stop := trackProgress(ctx, StageValidate, size, reader)
+	video, err := videoProbe.Probe(ctx, reader)
+	stop()
+	if reader.err != nil {
+		// Failing to read the file is not the applicant's fault.
+		return VideoInfo{}, reader.err
+	}
+	var probeErr *ProbeError
+	if errors.As(err, &probeErr) {
+		return VideoInfo{}, err
+	}
+	if err != nil {
+		return VideoInfo{}, cadence.NewCustomError(ReasonInvalidVideo, err.Error())
+	}
+	video.Size = size
+
+	if err := checkVideo(video, input.Limits); err != nil {
+		return video, cadence.NewCustomError(ReasonInvalidVideo, err.Error())
+	}
+	if input.Limits.MaxDuration > 0 && video.Duration == 0 {
+		logger.Warn("Video duration unknown, maximum duration not checked.", zap.String("container", video.Container))
+	}
+	logger.Info("Validate video activity ended", zap.String("container", video.Container), zap.Duration("duration", video.Duration))
+	return video, nil
+}
+
+// checkVideo checks a probed video against the limits of its step. Durations
+// the probe could not read are not checked.
+func checkVideo(video VideoInfo, limits UploadDefinition) error {
+	if len(limits.Containers) > 0 {
+		accepted := false
+		for _, container := range limits.Containers {
+			accepted = accepted || container == video.Container
+		}
+		if !accepted {
+			return fmt.Errorf("container %q not accepted", video.Container)
+		}
+	}
+	if limits.MaxDuration > 0 && video.Duration > limits.MaxDuration {
+		return fmt.Errorf("video of %s exceeds %s", video.Duration, limits.MaxDuration)
+	}
+	return nil
+}
+
+// storeVideoActivity copies a validated upload to its stored key and returns
+// the key as the reference to the video.
+func storeVideoActivity(ctx context.Context, input UploadInput) (string, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Store video activity started", zap.String("key", input.Key))
+	if objectStore == nil {
+		return "", errNoObjectStore
+	}
+
+	file, err := objectStore.Open(ctx, input.Key)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var size int64
+	if input.Video != nil {
+		size = input.Video.Size
+	}
+	reference := storedKey(input.Key)
+	reader := newProgressReader(ctx, file)
+	stop := trackProgress(ctx, StageStore, size, reader)
+	err = objectStore.Put(ctx, reference, reader)
+	stop()
+	if err != nil {
+		logger.Error("Store video failed.", zap.Error(err))
+		return "", err
+	}
+	logger.Info("Store video activity ended", zap.String("reference", reference))
+	return reference, nil
+}
+
+// attachVideoActivity adds the stored video to the applicant profile under
+// the step action.
+func attachVideoActivity(ctx context.Context, input UploadInput) (string, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Attach video activity started", zap.String("reference", input.Reference))
+	if profileBackend == nil {
+		return "", errNoProfileBackend
+	}
+
+	attributes := map[string]interface{}{
+		input.Action: map[string]interface{}{"reference": input.Reference, "video": input.Video},
+	}
+	result, err := newActivityCalls(ctx).run(StageAttach, func() (CallResult, error) {
+		status, err := profileBackend.PatchProfile(ctx, input.ApplicantID, attributes)
+		return CallResult{Status: status}, backendError(err)
+	})
+	if err != nil {
+		logger.Error("Attach video failed.", zap.String("status", result.Status), zap.Error(err))
+		return result.Status, err
+	}
+	logger.Info("Attach video activity ended", zap.String("status", result.Status))
+	return result.Status, nil
+}
+
+// progressReader counts the bytes read through it and stops reading once the
+// activity is cancelled. The first read error is kept in err.
+type progressReader struct {
+	ctx context.Context
+	r   io.Reader
+	n   int64
+	err error
+}
+
+func newProgressReader(ctx context.Context, r io.Reader) *progressReader {
+	return &progressReader{ctx: ctx, r: r}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	if err := p.ctx.Err(); err != nil {
+		p.err = err
+		return 0, err
+	}
+	n, err := p.r.Read(b)
+	atomic.AddInt64(&p.n, int64(n))
+	if err != nil && err != io.EOF && p.err == nil {
+		p.err = err
+	}
+	return n, err
+}
+
+// trackProgress heartbeats how far a stage has read through a file until the
+// returned stop function is called.
+func trackProgress(ctx context.Context, stage string, size int64, reader *progressReader) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				activity.RecordHeartbeat(ctx, UploadProgress{Stage: stage, Bytes: atomic.LoadInt64(&reader.n), Size: size})
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,97 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/BhanuChandraAraveti/cadence-example/app/adapters/objectstore"
+	"github.com/BhanuChandraAraveti/cadence-example/app/config"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/cadence"
+	"go.uber.org/cadence/testsuite"
+)
+
+type probeFunc func(ctx context.Context, r io.Reader) (VideoInfo, error)
+
+func (f probeFunc) Probe(ctx context.Context, r io.Reader) (VideoInfo, error) {
+	return f(ctx, r)
+}
+
+// withUploadAdapters sets the object store and video probe for a test and
+// restores the previous ones after it.
+func withUploadAdapters(t *testing.T, store ObjectStore, probe VideoProbe) {
+	previousStore, previousProbe := objectStore, videoProbe
+	SetObjectStore(store)
+	SetVideoProbe(probe)
+	t.Cleanup(func() {
+		SetObjectStore(previousStore)
+		SetVideoProbe(previousProbe)
+	})
+}
+
+func validateVideo(t *testing.T, limits UploadDefinition) (VideoInfo, error) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	value, err := env.ExecuteActivity(validateVideoActivity, UploadInput{Action: "lesson", Key: "uploads/lesson.mp4", Limits: limits})
+	if err != nil {
+		return VideoInfo{}, err
+	}
+	var video VideoInfo
+	require.NoError(t, value.Get(&video))
+	return video, nil
+}
+
+func TestValidateVideoNeedsAnObjectStore(t *testing.T) {
+	withUploadAdapters(t, nil, HeaderProbe{})
+
+	_, err := validateVideo(t, UploadDefinition{})
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "SetObjectStore")
+}
+
+func TestValidateVideoClassifiesProbeErrors(t *testing.T) {
+	store := objectstore.NewLocal(config.ObjectStoreConfig{Dir: t.TempDir(), Secret: "test"})
+	require.NoError(t, store.Put(context.Background(), "uploads/lesson.mp4", strings.NewReader("video")))
+
+	tests := []struct {
+		name    string
+		probe   probeFunc
+		invalid bool
+	}{
+		{"invalid video", func(ctx context.Context, r io.Reader) (VideoInfo, error) {
+			return VideoInfo{}, errors.New("unknown container")
+		}, true},
+		{"probe failure", func(ctx context.Context, r io.Reader) (VideoInfo, error) {
+			return VideoInfo{}, &ProbeError{Err: errors.New("ffprobe not found")}
+		}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			withUploadAdapters(t, store, test.probe)
+
+			_, err := validateVideo(t, UploadDefinition{})
+
+			require.Error(t, err)
+			var customErr *cadence.CustomError
+			require.Equal(t, test.invalid, errors.As(err, &customErr) && customErr.Reason() == ReasonInvalidVideo, "%v", err)
+		})
+	}
+
+	t.Run("valid video", func(t *testing.T) {
+		withUploadAdapters(t, store, probeFunc(func(ctx context.Context, r io.Reader) (VideoInfo, error) {
+			_, err := ioutil.ReadAll(r)
+			return VideoInfo{Container: "webm"}, err
+		}))
+
+		video, err := validateVideo(t, UploadDefinition{Containers: []string{"webm"}})
+
+		require.NoError(t, err)
+		require.Equal(t, VideoInfo{Container: "webm", Size: 5}, video)
+	})
+}
@@ -4,6 +4,7 @@ go 1.19
 
 require (
 	github.com/spf13/viper v1.15.0
+	github.com/stretchr/testify v1.8.1
 	github.com/uber-go/tally v3.5.3+incompatible
 	go.uber.org/cadence v0.19.1
 	go.uber.org/yarpc v1.70.2
@@ -43,7 +44,6 @@ require (
 	github.com/spf13/jwalterweatherman v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/stretchr/objx v0.5.0 // indirect
-	github.com/stretchr/testify v1.8.1 // indirect
 	github.com/subosito/gotenv v1.4.2 // indirect
 	github.com/twmb/murmur3 v1.1.7 // indirect
 	github.com/uber-go/mapdecode v1.0.0 // indirect